@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/roryq/required-checks/pkg/pullrequest"
+	"github.com/roryq/required-checks/pkg/reqcheck"
+	"github.com/roryq/required-checks/pkg/reqcheck/inputs"
+)
+
+const usage = `Usage: required-checks <command> [flags]
+
+Without a command required-checks runs as a GitHub Action, reading its inputs from the environment.
+
+Commands:
+  wait       Wait for the required checks on a commit to complete
+  explain    Explain which checks would be required for a list of changed files and check names
+  validate   Validate config files, defaulting to .github/required-checks.yaml
+`
+
+// envPrefix is the prefix of the environment variable equivalent of each flag, e.g. --poll-frequency-seconds can be
+// set with REQUIRED_CHECKS_POLL_FREQUENCY_SECONDS.
+const envPrefix = "REQUIRED_CHECKS_"
+
+// cliFlag is a command line flag, and the action input it sets if any.
+type cliFlag struct {
+	Name  string
+	Input string
+	Usage string
+}
+
+// patternFlags set the patterns that are required.
+var patternFlags = []cliFlag{
+	{Name: "config", Usage: "path to a yaml config file, using the same keys as the action inputs"},
+	{Name: "token", Input: inputs.Token, Usage: "GitHub token, defaults to $GITHUB_TOKEN"},
+	{Name: "required-workflow-patterns", Input: inputs.RequiredWorkflowPatterns, Usage: "yaml list of regex patterns to check"},
+	{Name: "conditional-path-workflow-patterns", Input: inputs.ConditionalPathWorkflowPatterns, Usage: "yaml dictionary of path globs and regex patterns to check"},
+	{Name: "check-apps", Input: inputs.CheckApps, Usage: "yaml dictionary of regex patterns and the GitHub Apps allowed to report them"},
+	{Name: "conclusion-policy", Input: inputs.ConclusionPolicy, Usage: "yaml dictionary of check conclusions and their verdict"},
+}
+
+var waitFlags = append([]cliFlag{
+	{Name: "repo", Usage: "repository owner/name, defaults to $GITHUB_REPOSITORY"},
+	{Name: "sha", Input: inputs.TargetSHA, Usage: "commit SHA the checks have been run against"},
+	{Name: "pr", Usage: "pull request number, used to list changed files for conditional path patterns"},
+	{Name: "base-sha", Usage: "base commit SHA, used without --pr or with --git-changed-files to list the files changed since its merge base with --sha"},
+}, append(patternFlags, []cliFlag{
+	{Name: "initial-delay-seconds", Input: inputs.InitialDelaySeconds, Usage: "initial delay before polling"},
+	{Name: "poll-frequency-seconds", Input: inputs.PollFrequencySeconds, Usage: "polling frequency"},
+	{Name: "missing-required-retry-count", Input: inputs.MissingRequiredRetryCount, Usage: "number of times to retry if a required check is missing"},
+	{Name: "timeout", Input: inputs.Timeout, Usage: "overall time to wait for required checks, e.g. 30m"},
+	{Name: "check-run-name", Input: inputs.CheckRunName, Usage: "name of a check run to publish the result to"},
+	{Name: "pr-comment", Input: inputs.PRComment, Usage: "create or update a comment on the pull request with the result, true or false"},
+	{Name: "git-changed-files", Input: inputs.GitChangedFiles, Usage: "list the changed files from the local git checkout instead of the GitHub API, true or false"},
+}...)...)
+
+// flagValues are the values of the flags that were set, falling back to their environment variable equivalents.
+type flagValues map[string]string
+
+func parseFlags(name string, flags []cliFlag, args []string, getenv func(string) string) (flagValues, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	parsed := map[string]*string{}
+	for _, f := range flags {
+		parsed[f.Name] = fs.String(f.Name, "", fmt.Sprintf("%s [$%s]", f.Usage, envName(f.Name)))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	values := flagValues{}
+	for _, f := range flags {
+		if v := getenv(envName(f.Name)); v != "" {
+			values[f.Name] = v
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		values[f.Name] = *parsed[f.Name]
+	})
+	return values, nil
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// getInput returns the value of the flag for the action input, so that the flags are read the same way as the
+// action inputs.
+func (v flagValues) getInput(flags []cliFlag) func(string) string {
+	return func(input string) string {
+		for _, f := range flags {
+			if f.Input == input {
+				return v[f.Name]
+			}
+		}
+		return ""
+	}
+}
+
+func runCLI(ctx context.Context, args []string, stdout io.Writer) error {
+	switch args[0] {
+	case "wait":
+		return runWait(ctx, args[1:], stdout)
+	case "explain":
+		return runExplain(ctx, args[1:], stdout)
+	case "validate":
+		return runValidate(args[1:], stdout)
+	case "help", "-h", "--help":
+		_, err := fmt.Fprint(stdout, usage)
+		return err
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func runWait(ctx context.Context, args []string, stdout io.Writer) error {
+	values, err := parseFlags("wait", waitFlags, args, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	repo := values["repo"]
+	if repo == "" {
+		repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" {
+		return fmt.Errorf("--repo must be owner/name, got %q", repo)
+	}
+
+	if values["sha"] == "" {
+		return errors.New("--sha is required")
+	}
+
+	number := pullrequest.None[int]()
+	if pr := values["pr"]; pr != "" {
+		n, err := strconv.Atoi(pr)
+		if err != nil {
+			return fmt.Errorf("--pr must be a number: %w", err)
+		}
+		number = pullrequest.Some(n)
+	}
+
+	if values["token"] == "" {
+		values["token"] = os.Getenv("GITHUB_TOKEN")
+	}
+
+	reporter := reqcheck.LogReporter{W: stdout, Debug: os.Getenv("REQUIRED_CHECKS_DEBUG") != ""}
+	env := reqcheck.Environment{
+		Owner:    owner,
+		Repo:     name,
+		PRNumber: number.V,
+		BaseSHA:  values["base-sha"],
+		HeadSHA:  values["sha"],
+	}
+
+	gh := newGitHubClient(ctx, values["token"])
+	pr := pullrequest.New(gh, owner, name, number)
+
+	cfg, err := loadConfig(ctx, values, waitFlags, reporter, pr)
+	if err != nil {
+		return err
+	}
+	cfg.TargetSHA = values["sha"]
+	if !number.Valid && env.BaseSHA == "" && len(cfg.ConditionalPathWorkflowPatterns) > 0 {
+		reporter.Warningf("Neither --pr nor --base-sha is set, so the changed files cannot be listed and the conditional path patterns are not required")
+	}
+
+	return reqcheck.Wait(ctx, cfg, env, reporter, pr)
+}
+
+// loadConfig reads the config file, if the config flag is set, and overrides it with the flags.
+func loadConfig(ctx context.Context, values flagValues, flags []cliFlag, reporter reqcheck.Reporter, contents reqcheck.ContentsClient) (*reqcheck.Config, error) {
+	cfg := reqcheck.DefaultConfig()
+	var file *reqcheck.ConfigFile
+	if path := values["config"]; path != "" {
+		var err error
+		file, err = reqcheck.ReadConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := file.Apply(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := reqcheck.ApplyInputs(values.getInput(flags), reporter, cfg); err != nil {
+		return nil, err
+	}
+	if file != nil && file.Extends != "" {
+		inherited, err := reqcheck.LoadExtends(ctx, reporter, contents, file.Extends)
+		if err != nil {
+			return nil, err
+		}
+		reqcheck.MergeInherited(cfg, inherited, reporter)
+		reporter.Infof("Effective config:\n%s", reqcheck.ConfigFileFrom(cfg))
+	}
+	return cfg, nil
+}
+
+// runValidate validates each config file, printing the problems with their line and column.
+func runValidate(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: required-checks validate [config files]\n")
+	}
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{reqcheck.DefaultConfigPath}
+	}
+
+	invalid := 0
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		errs := reqcheck.ValidateConfigFile(b)
+		if len(errs) == 0 {
+			fmt.Fprintf(stdout, "%s: valid\n", path)
+			continue
+		}
+		invalid++
+		for _, e := range errs {
+			if e.Line == 0 {
+				fmt.Fprintf(stdout, "%s: %s\n", path, e.Message)
+			} else {
+				fmt.Fprintf(stdout, "%s:%d:%d: %s\n", path, e.Line, e.Column, e.Message)
+			}
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d config files are invalid", invalid, len(paths))
+	}
+	return nil
+}
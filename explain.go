@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/samber/lo"
+
+	"github.com/roryq/required-checks/pkg/pullrequest"
+	"github.com/roryq/required-checks/pkg/reqcheck"
+)
+
+var explainFlags = append([]cliFlag{
+	{Name: "files", Usage: "comma or newline separated list of changed files, optionally with their status as output by git diff --name-status"},
+	{Name: "files-from", Usage: "path to a file listing the changed files, one per line, e.g. the output of git diff --name-status"},
+	{Name: "checks", Usage: `comma or newline separated list of check names, prefixed with "status:" for commit statuses`},
+	{Name: "checks-from", Usage: "path to a file listing the check names, one per line"},
+	{Name: "event", Usage: "path to a saved GitHub event JSON, used to list the changed files and checks of its pull request or push that are not listed by the other flags"},
+}, patternFlags...)
+
+// runExplain prints which path globs match the changed files, which patterns are required, and which checks each
+// pattern matches, without waiting for any checks.
+func runExplain(ctx context.Context, args []string, stdout io.Writer) error {
+	values, err := parseFlags("explain", explainFlags, args, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	files, err := readList(values["files"], values["files-from"])
+	if err != nil {
+		return err
+	}
+	checkNames, err := readList(values["checks"], values["checks-from"])
+	if err != nil {
+		return err
+	}
+
+	var env reqcheck.Environment
+	if path := values["event"]; path != "" {
+		event, err := readEvent(path)
+		if err != nil {
+			return err
+		}
+		env = reqcheck.EnvironmentFromEvent(event)
+		if env.EventName == "" {
+			return fmt.Errorf("event %s is not a pull_request, merge_group, check_run or push event", path)
+		}
+		if env.Owner == "" || env.Repo == "" {
+			return fmt.Errorf("event %s has no repository", path)
+		}
+	}
+
+	if values["token"] == "" {
+		values["token"] = os.Getenv("GITHUB_TOKEN")
+	}
+	reporter := reqcheck.LogReporter{W: stdout, Debug: os.Getenv("REQUIRED_CHECKS_DEBUG") != ""}
+	number := pullrequest.None[int]()
+	if env.PRNumber != 0 {
+		number = pullrequest.Some(env.PRNumber)
+	}
+	pr := pullrequest.New(newGitHubClient(ctx, values["token"]), env.Owner, env.Repo, number)
+	cfg, err := loadConfig(ctx, values, explainFlags, reporter, pr)
+	if err != nil {
+		return err
+	}
+
+	changed := lo.Map(files, func(item string, _ int) reqcheck.ChangedFile { return reqcheck.ParseChangedFile(item) })
+	if len(changed) == 0 && values["event"] != "" {
+		changed, err = listEventFiles(ctx, env, cfg, reporter, pr)
+		if err != nil {
+			return err
+		}
+	}
+
+	checks := namedChecks(checkNames)
+	if len(checks) == 0 && values["event"] != "" {
+		if env.HeadSHA == "" {
+			return fmt.Errorf("cannot list the checks of the %s event: no head commit", env.EventName)
+		}
+		checks, err = reqcheck.ListChecks(ctx, pr, env.HeadSHA)
+		if err != nil {
+			return fmt.Errorf("list the checks of %s: %w", env.HeadSHA, err)
+		}
+	}
+	return explain(stdout, cfg, changed, checks)
+}
+
+// listEventFiles lists the files changed by the pull request of the event, or between the before and after commits of
+// a push.
+func listEventFiles(ctx context.Context, env reqcheck.Environment, cfg *reqcheck.Config, reporter reqcheck.Reporter, pr reqcheck.PRClient) ([]reqcheck.ChangedFile, error) {
+	if env.PRNumber == 0 && env.EventName != "push" && env.EventName != "merge_group" {
+		return nil, fmt.Errorf("cannot list the changed files of the %s event: no pull request or commits to compare", env.EventName)
+	}
+	files, err := reqcheck.ListChangedFiles(ctx, env, cfg, reporter, pr)
+	if err != nil {
+		return nil, fmt.Errorf("list the changed files of the %s event: %w", env.EventName, err)
+	}
+	return files, nil
+}
+
+// namedChecks are the checks with the names, prefixed with "status:" for commit statuses. Checks listed by name have
+// no state, so they are explained as if they passed.
+func namedChecks(names []string) []reqcheck.Check {
+	return lo.Map(names, func(item string, _ int) reqcheck.Check {
+		source := reqcheck.SourceCheckRun
+		if name, ok := strings.CutPrefix(item, reqcheck.SourceStatus+":"); ok {
+			source, item = reqcheck.SourceStatus, name
+		}
+		return reqcheck.Check{CheckRun: &github.CheckRun{
+			Name:       github.String(item),
+			Status:     github.String(reqcheck.StatusCompleted),
+			Conclusion: github.String(reqcheck.ConclusionSuccess),
+		}, Source: source}
+	})
+}
+
+func explain(w io.Writer, cfg *reqcheck.Config, files []reqcheck.ChangedFile, checks []reqcheck.Check) error {
+	var patterns []reqcheck.Pattern
+	fmt.Fprintf(w, "Changed files: %d\n", len(files))
+	if len(cfg.ConditionalPathWorkflowPatterns) > 0 {
+		fmt.Fprintln(w, "Path globs:")
+		for _, m := range reqcheck.MatchPaths(cfg.ConditionalPathWorkflowPatterns, files) {
+			if !m.Matched() {
+				fmt.Fprintf(w, "  %s did not match any changed files\n", m)
+				continue
+			}
+			fmt.Fprintf(w, "  %s matched, requiring %q:\n", m, reqcheck.PatternNames(m.Patterns))
+			for _, f := range m.Files {
+				file := f.Filename
+				if f.Status != "" {
+					file += " (" + f.Status + ")"
+				}
+				fmt.Fprintf(w, "    %s\n", file)
+			}
+			patterns = append(patterns, m.Patterns...)
+		}
+	}
+
+	rules, err := cfg.Ruleset(patterns)
+	if err != nil {
+		return err
+	}
+
+	d := reqcheck.Evaluate(reqcheck.Snapshot{Checks: checks, Now: time.Now()}, rules, reqcheck.Environment{})
+
+	fmt.Fprintln(w, "Required patterns:")
+	for _, p := range d.Patterns {
+		fmt.Fprintf(w, "  %s%s\n", p.Rule, ruleDetails(p.Rule))
+		fmt.Fprintf(w, "    %s: %s\n", p.Outcome, p.Explanation)
+		if len(p.Matches) > 0 {
+			fmt.Fprintf(w, "    matched %q\n", matchNames(p.Matches))
+		}
+	}
+	for _, m := range d.Suspicious {
+		fmt.Fprintf(w, "Check %q matches %s, but was reported by an app that is not allowed\n", m.GetName(), m.Rule)
+	}
+	matched := lo.Map(append(d.Matches, d.Suspicious...), func(item reqcheck.Match, _ int) reqcheck.Check { return item.Check })
+	if unmatched, _ := lo.Difference(checks, matched); len(unmatched) > 0 {
+		fmt.Fprintf(w, "Checks not required: %q\n", lo.Map(unmatched, func(item reqcheck.Check, _ int) string { return item.GetName() }))
+	}
+	fmt.Fprintf(w, "Outcome: %s\n", d.Outcome)
+	return nil
+}
+
+// ruleDetails describes the options of the rule that affect which checks it matches.
+func ruleDetails(r *reqcheck.Rule) string {
+	var details []string
+	if r.MinCount > 1 {
+		details = append(details, fmt.Sprintf("min_count %d", r.MinCount))
+	}
+	if len(r.Apps) > 0 {
+		details = append(details, fmt.Sprintf("apps %q", r.Apps))
+	}
+	if r.Description != "" {
+		details = append(details, r.Description)
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+func matchNames(matches []reqcheck.Match) []string {
+	return lo.Map(matches, func(item reqcheck.Match, _ int) string { return item.GetName() })
+}
+
+// readList splits the comma or newline separated list, and the lines of the file at path if set.
+func readList(list, path string) ([]string, error) {
+	items := strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' })
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, strings.Split(string(b), "\n")...)
+	}
+	items = lo.Map(items, func(item string, _ int) string { return strings.TrimSpace(item) })
+	return lo.Compact(items), nil
+}
+
+func readEvent(path string) (map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var event map[string]any
+	if err := json.Unmarshal(b, &event); err != nil {
+		return nil, fmt.Errorf("parse event %s: %w", path, err)
+	}
+	return event, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/roryq/required-checks/pkg/reqcheck"
+	"github.com/roryq/required-checks/pkg/xassert"
+)
+
+func TestExplain(t *testing.T) {
+	cfg := &reqcheck.Config{
+		RequiredWorkflowPatterns: reqcheck.NewPatterns("build"),
+		ConditionalPathWorkflowPatterns: map[string]reqcheck.PathRule{
+			"**/*.go": {Patterns: reqcheck.NewPatterns("go-tests")},
+			"docs/**": {Patterns: reqcheck.NewPatterns("docs")},
+		},
+	}
+	testCases := map[string]struct {
+		cfg         *reqcheck.Config
+		files       []reqcheck.ChangedFile
+		checks      []string
+		expected    string
+		assertError assert.ErrorAssertionFunc
+	}{
+		"passed": {
+			cfg:    cfg,
+			files:  []reqcheck.ChangedFile{{Filename: "pkg/main.go"}, {Filename: "README.md"}, {Filename: "pkg/cli.go"}},
+			checks: []string{"build", "go-tests (1.22)", "lint"},
+			expected: `Changed files: 3
+Path globs:
+  **/*.go matched, requiring ["go-tests"]:
+    pkg/main.go
+    pkg/cli.go
+  docs/** did not match any changed files
+Required patterns:
+  build
+    passed: all 1 matched checks passed
+    matched ["build"]
+  go-tests
+    passed: all 1 matched checks passed
+    matched ["go-tests (1.22)"]
+Checks not required: ["lint"]
+Outcome: passed
+`,
+			assertError: assert.NoError,
+		},
+		"missing": {
+			cfg:    cfg,
+			files:  []reqcheck.ChangedFile{{Filename: "docs/index.md", Status: reqcheck.FileAdded}},
+			checks: []string{"build"},
+			expected: `Changed files: 1
+Path globs:
+  **/*.go did not match any changed files
+  docs/** matched, requiring ["docs"]:
+    docs/index.md (added)
+Required patterns:
+  build
+    passed: all 1 matched checks passed
+    matched ["build"]
+  docs
+    missing: no checks matched
+Outcome: missing
+`,
+			assertError: assert.NoError,
+		},
+		"invalid pattern": {
+			cfg:         &reqcheck.Config{RequiredWorkflowPatterns: reqcheck.NewPatterns("(build")},
+			assertError: xassert.ErrorContains("missing closing )"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+
+			err := explain(out, tc.cfg, tc.files, namedChecks(tc.checks))
+
+			tc.assertError(t, err)
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestNamedChecks(t *testing.T) {
+	checks := namedChecks([]string{"tests", "status:ci/jenkins"})
+
+	assert.Equal(t, []reqcheck.Check{
+		{CheckRun: &github.CheckRun{Name: github.String("tests"), Status: github.String(reqcheck.StatusCompleted), Conclusion: github.String(reqcheck.ConclusionSuccess)}, Source: reqcheck.SourceCheckRun},
+		{CheckRun: &github.CheckRun{Name: github.String("ci/jenkins"), Status: github.String(reqcheck.StatusCompleted), Conclusion: github.String(reqcheck.ConclusionSuccess)}, Source: reqcheck.SourceStatus},
+	}, checks)
+}
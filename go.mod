@@ -3,6 +3,7 @@ module github.com/roryq/required-checks
 go 1.24
 
 require (
+	github.com/bmatcuk/doublestar/v4 v4.9.0
 	github.com/google/go-github/v61 v61.0.0
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e
 	github.com/samber/lo v1.49.1
@@ -13,7 +14,6 @@ require (
 )
 
 require (
-	github.com/bmatcuk/doublestar/v4 v4.9.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
@@ -2,40 +2,94 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/google/go-github/v61/github"
 	"github.com/sethvargo/go-githubactions"
 	"golang.org/x/oauth2"
 
+	"github.com/roryq/required-checks/pkg/pullrequest"
 	"github.com/roryq/required-checks/pkg/reqcheck"
 	"github.com/roryq/required-checks/pkg/reqcheck/inputs"
 )
 
-func run() error {
-	ctx := context.Background()
+// Exit codes for each category of error, so that scripts can react differently to failed, missing or timed out
+// checks.
+const (
+	exitError   = 1
+	exitFailed  = 2
+	exitMissing = 3
+	exitTimeout = 4
+)
+
+func exitCode(err error) int {
+	var (
+		failedErr  *reqcheck.FailedChecksError
+		missingErr *reqcheck.MissingChecksError
+		timeoutErr *reqcheck.TimeoutError
+	)
+	switch {
+	case errors.As(err, &failedErr):
+		return exitFailed
+	case errors.As(err, &missingErr):
+		return exitMissing
+	case errors.As(err, &timeoutErr):
+		return exitTimeout
+	default:
+		return exitError
+	}
+}
+
+func run(ctx context.Context) error {
 	action := githubactions.New()
+	gh := newGitHubClient(ctx, action.GetInput(inputs.Token))
+
+	pr, err := pullrequest.NewClient(action, gh)
+	if err != nil {
+		return err
+	}
 
-	cfg, err := reqcheck.ConfigFromInputs(action)
+	cfg, err := reqcheck.ConfigFromInputs(ctx, action, pr)
 	if err != nil {
 		return err
 	}
 
+	return reqcheck.Run(ctx, cfg, action, gh)
+}
+
+func newGitHubClient(ctx context.Context, token string) *github.Client {
 	var tc *http.Client
-	if token := action.GetInput(inputs.Token); token != "" {
+	if token != "" {
 		ts := oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: token},
 		)
 		tc = oauth2.NewClient(ctx, ts)
 	}
 
-	gh := github.NewClient(tc)
-	return reqcheck.Run(ctx, cfg, action, gh)
+	return github.NewClient(tc)
 }
 
 func main() {
-	err := run()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Run as a GitHub Action unless a command is given.
+	if len(os.Args) > 1 {
+		if err := runCLI(ctx, os.Args[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
+	err := run(ctx)
 	if err != nil {
-		githubactions.Fatalf("%v", err)
+		githubactions.Errorf("%v", err)
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/roryq/required-checks/pkg/reqcheck"
+	"github.com/roryq/required-checks/pkg/xassert"
+)
+
+func TestParseFlags(t *testing.T) {
+	testCases := map[string]struct {
+		args        []string
+		env         map[string]string
+		expected    flagValues
+		assertError assert.ErrorAssertionFunc
+	}{
+		"flags": {
+			args:        []string{"--sha", "flag-sha", "--poll-frequency-seconds", "10"},
+			expected:    flagValues{"sha": "flag-sha", "poll-frequency-seconds": "10"},
+			assertError: assert.NoError,
+		},
+		"environment fallback": {
+			env:         map[string]string{"REQUIRED_CHECKS_SHA": "env-sha", "REQUIRED_CHECKS_POLL_FREQUENCY_SECONDS": "10"},
+			expected:    flagValues{"sha": "env-sha", "poll-frequency-seconds": "10"},
+			assertError: assert.NoError,
+		},
+		"flags override environment": {
+			args:        []string{"--sha", "flag-sha"},
+			env:         map[string]string{"REQUIRED_CHECKS_SHA": "env-sha", "REQUIRED_CHECKS_PR": "123"},
+			expected:    flagValues{"sha": "flag-sha", "pr": "123"},
+			assertError: assert.NoError,
+		},
+		"ignores unknown environment variables": {
+			env:         map[string]string{"REQUIRED_CHECKS_UNKNOWN": "value"},
+			expected:    flagValues{},
+			assertError: assert.NoError,
+		},
+		"unknown flag": {
+			args:        []string{"--unknown", "value"},
+			assertError: xassert.ErrorContains("flag provided but not defined: -unknown"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			getenv := func(key string) string { return tc.env[key] }
+
+			values, err := parseFlags("wait", waitFlags, tc.args, getenv)
+
+			tc.assertError(t, err)
+			assert.Equal(t, tc.expected, values)
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected int
+	}{
+		"failed": {
+			err:      &reqcheck.FailedChecksError{},
+			expected: exitFailed,
+		},
+		"missing": {
+			err:      &reqcheck.MissingChecksError{},
+			expected: exitMissing,
+		},
+		"timeout": {
+			err:      &reqcheck.TimeoutError{},
+			expected: exitTimeout,
+		},
+		"wrapped": {
+			err:      fmt.Errorf("wait: %w", &reqcheck.FailedChecksError{}),
+			expected: exitFailed,
+		},
+		"other": {
+			err:      errors.New("bad credentials"),
+			expected: exitError,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, exitCode(tc.err))
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/google/go-github/v61/github"
@@ -47,6 +49,28 @@ func (pr Client) ListChecks(ctx context.Context, sha string, options *github.Lis
 	return checks, nil
 }
 
+// ListStatuses lists the latest commit status for each context reported against the sha.
+func (pr Client) ListStatuses(ctx context.Context, sha string, options *github.ListOptions) ([]*github.RepoStatus, error) {
+	var statuses []*github.RepoStatus
+	for {
+		combined, resp, err := pr.gh.Repositories.GetCombinedStatus(ctx, pr.Owner, pr.Repo, sha, options)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, combined.Statuses...)
+		if resp.NextPage == 0 {
+			break
+		}
+		if options == nil {
+			options = &github.ListOptions{
+				Page: resp.NextPage,
+			}
+		}
+		options.Page = resp.NextPage
+	}
+	return statuses, nil
+}
+
 func (pr Client) ListFiles(ctx context.Context, options *github.ListOptions) ([]*github.CommitFile, error) {
 	if !pr.Number.Valid {
 		return nil, nil
@@ -71,6 +95,107 @@ func (pr Client) ListFiles(ctx context.Context, options *github.ListOptions) ([]
 	return files, nil
 }
 
+// CompareFiles lists the files changed between the base and head commits.
+func (pr Client) CompareFiles(ctx context.Context, base, head string) ([]*github.CommitFile, error) {
+	comparison, _, err := pr.gh.Repositories.CompareCommits(ctx, pr.Owner, pr.Repo, base, head, nil)
+	if err != nil {
+		return nil, err
+	}
+	return comparison.Files, nil
+}
+
+// CreateCheckRun creates a check run in the repository.
+func (pr Client) CreateCheckRun(ctx context.Context, opts github.CreateCheckRunOptions) (*github.CheckRun, error) {
+	run, _, err := pr.gh.Checks.CreateCheckRun(ctx, pr.Owner, pr.Repo, opts)
+	return run, err
+}
+
+// UpdateCheckRun updates the check run with the id in the repository.
+func (pr Client) UpdateCheckRun(ctx context.Context, id int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, error) {
+	run, _, err := pr.gh.Checks.UpdateCheckRun(ctx, pr.Owner, pr.Repo, id, opts)
+	return run, err
+}
+
+// ListComments lists the comments on the pull request.
+func (pr Client) ListComments(ctx context.Context) ([]*github.IssueComment, error) {
+	if !pr.Number.Valid {
+		return nil, nil
+	}
+	var comments []*github.IssueComment
+	options := &github.IssueListCommentsOptions{}
+	for {
+		commentsPage, resp, err := pr.gh.Issues.ListComments(ctx, pr.Owner, pr.Repo, pr.Number.V, options)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, commentsPage...)
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+	return comments, nil
+}
+
+// githubActionsLogin is the user that the GITHUB_TOKEN of a workflow run acts as.
+const githubActionsLogin = "github-actions[bot]"
+
+// CommentAuthor is the login of the user that comments as the token. Installation tokens, such as the GITHUB_TOKEN,
+// cannot get the authenticated user, so comment as github-actions[bot].
+func (pr Client) CommentAuthor(ctx context.Context) (string, error) {
+	user, _, err := pr.gh.Users.Get(ctx, "")
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response.StatusCode == http.StatusForbidden {
+		return githubActionsLogin, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return user.GetLogin(), nil
+}
+
+// CreateComment comments on the pull request.
+func (pr Client) CreateComment(ctx context.Context, body string) error {
+	if !pr.Number.Valid {
+		return errors.New("no pull request to comment on")
+	}
+	_, _, err := pr.gh.Issues.CreateComment(ctx, pr.Owner, pr.Repo, pr.Number.V, &github.IssueComment{Body: github.String(body)})
+	return err
+}
+
+// EditComment replaces the body of the pull request comment with the id.
+func (pr Client) EditComment(ctx context.Context, id int64, body string) error {
+	_, _, err := pr.gh.Issues.EditComment(ctx, pr.Owner, pr.Repo, id, &github.IssueComment{Body: github.String(body)})
+	return err
+}
+
+// GetContents gets the contents of the file at path in the repository at ref.
+func (pr Client) GetContents(ctx context.Context, owner, repo, path, ref string) ([]byte, error) {
+	file, _, _, err := pr.gh.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// New creates a client for the repository, and the pull request number if there is one.
+func New(gh *github.Client, owner, repo string, number Option[int]) Client {
+	return Client{
+		Owner:  owner,
+		Repo:   repo,
+		Number: number,
+		gh:     gh,
+	}
+}
+
+// NewClient creates a client for the repository and pull request of the action's event.
 func NewClient(action *githubactions.Action, gh *github.Client) (Client, error) {
 	ctx, err := action.Context()
 	if err != nil {
@@ -79,8 +204,8 @@ func NewClient(action *githubactions.Action, gh *github.Client) (Client, error)
 
 	var number Option[int]
 	switch ctx.EventName {
-	case "merge_group":
-		// no PR number available for merge_group
+	case "merge_group", "push":
+		// no PR number available for merge_group or push
 	default:
 		n, err := getPRNumber(ctx.Event)
 		if err != nil {
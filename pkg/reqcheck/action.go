@@ -8,10 +8,10 @@ import (
 	"net/http"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/bmatcuk/doublestar/v4"
 	"github.com/google/go-github/v61/github"
 	"github.com/samber/lo"
 	"github.com/sethvargo/go-githubactions"
@@ -20,161 +20,334 @@ import (
 	"github.com/roryq/required-checks/pkg/pullrequest"
 )
 
+// Run waits for the required checks of a GitHub Actions workflow run, reading the environment from the action and
+// reporting to the action log.
 func Run(ctx context.Context, cfg *Config, action *githubactions.Action, gh *github.Client) error {
+	env, err := EnvironmentFromAction(action)
+	if err != nil {
+		return err
+	}
+
 	pr, err := pullrequest.NewClient(action, gh)
 	if err != nil {
 		return err
 	}
 
-	return run(ctx, cfg, action, pr)
+	return Wait(ctx, cfg, env, ActionReporter{action}, pr)
+}
+
+// Wait polls the checks reported against cfg.TargetSHA until all the required checks have completed, returning an
+// error if any are missing, fail, or time out. Waiting stops with the context error if the context is cancelled.
+func Wait(ctx context.Context, cfg *Config, env Environment, reporter Reporter, pr PRClient) error {
+	return run(ctx, cfg, env, reporter, pr, RealClock)
 }
 
-// run is the same as Run but takes a function for listing checks, useful for testing
-func run(ctx context.Context, cfg *Config, action *githubactions.Action, pr PRClient) error {
-	action.Infof("Waiting %s before initial check", cfg.InitialDelay)
-	time.Sleep(cfg.InitialDelay)
+// run is the same as Wait but takes a clock, useful for testing.
+func run(ctx context.Context, cfg *Config, env Environment, reporter Reporter, pr PRClient, clock Clock) (err error) {
+	// Write the summary of the last poll, complete the published check run, and comment on the pull request, however
+	// waiting finishes.
+	report := &summary{}
+	var publisher *checkRunPublisher
+	defer func() {
+		report.write(reporter, err)
+		publisher.complete(context.WithoutCancel(ctx), report, err, clock)
+		writeComment(context.WithoutCancel(ctx), cfg, env, reporter, pr, report, err)
+	}()
 
-	ghCtx, err := action.Context()
+	// Fail on invalid patterns before waiting.
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	publisher, err = startCheckRun(ctx, cfg, reporter, pr, clock)
 	if err != nil {
 		return err
 	}
 
-	workflowPatterns := cfg.RequiredWorkflowPatterns
-	pathWorkflowPatterns, err := getConditionalPathPatterns(ctx, ghCtx, cfg, action, pr)
+	start := clock.Now()
+	deadline := start.Add(cfg.Timeout)
+	if cfg.Timeout > 0 {
+		reporter.Infof("Timing out after %s", cfg.Timeout)
+	}
+
+	// waitDuration is the poll frequency, or the remaining time if the deadline is sooner.
+	waitDuration := func() time.Duration {
+		if cfg.Timeout > 0 {
+			return max(min(cfg.PollFrequency, deadline.Sub(clock.Now())), 0)
+		}
+		return cfg.PollFrequency
+	}
+	timedOut := func() bool {
+		return cfg.Timeout > 0 && !clock.Now().Before(deadline)
+	}
+
+	reporter.Infof("Waiting %s before initial check", cfg.InitialDelay)
+	if err := sleep(ctx, clock, cfg.InitialDelay); err != nil {
+		return err
+	}
+
+	pathWorkflowPatterns, err := getConditionalPathPatterns(ctx, env, cfg, reporter, pr, report)
 	if err != nil {
 		return err
 	}
 
-	workflowPatterns = lo.Uniq(append(workflowPatterns, pathWorkflowPatterns...))
-	rules, err := NewRuleset(workflowPatterns)
+	rules, err := cfg.Ruleset(pathWorkflowPatterns)
 	if err != nil {
 		return err
 	}
+	// Until the first poll, no checks have been reported.
+	report.decision = Evaluate(Snapshot{Now: clock.Now()}, rules, env)
 
 	missingRequiredCount := 0
-	foundSelf := false
+	suspicious := map[string]bool{}
+	firstSeen := map[string]time.Time{}
 	for {
-		checks, err := pr.ListChecks(ctx, cfg.TargetSHA, nil)
+		checks, err := ListChecks(ctx, pr, cfg.TargetSHA)
 		if err != nil {
 			// Retry if we get an unexpected EOF error, which could be due to proxies.
 			if errors.Is(err, io.ErrUnexpectedEOF) {
-				action.Infof("Unexpected EOF, retrying...")
-				time.Sleep(cfg.PollFrequency)
+				if timedOut() {
+					return &TimeoutError{Timeout: cfg.Timeout}
+				}
+				reporter.Infof("Unexpected EOF, retrying...")
+				if err := sleep(ctx, clock, waitDuration()); err != nil {
+					return err
+				}
 				continue
 			}
 			return err
 		}
-		action.Infof("Got %d checks", len(checks))
-		action.Infof("Checks: %q", checkNames(checks))
+		reporter.Infof("Got %d checks", len(checks))
+		reporter.Infof("Checks: %q", checkNames(checks))
 
-		requiredSet := lo.SliceToMap(workflowPatterns, func(item string) (string, bool) { return item, false })
-		toCheck := []*github.CheckRun{}
+		now := clock.Now()
 		for _, c := range checks {
-			if strings.Contains(c.GetDetailsURL(), fmt.Sprintf("runs/%d/job", ghCtx.RunID)) {
-				// skip waiting for this check if this is named the same as another check
-				if !foundSelf && c.GetName() == ghCtx.Job {
-					action.Infof("Skipping check: %q", c.GetName())
-					foundSelf = true
-					continue
-				}
-			}
-			if found := rules.First(c.GetName()); found != nil {
-				toCheck = append(toCheck, c)
-				requiredSet[found.String()] = true
+			if _, ok := firstSeen[checkKey(c)]; !ok {
+				firstSeen[checkKey(c)] = now
 			}
 		}
 
-		// If required is not found, retry in case the workflow is still being created then fail as there will not be a successful check.
-		requiredNotFound := lo.OmitByValues(requiredSet, []bool{true})
-		if len(requiredNotFound) > 0 {
-			missingRequiredCount++
-			if missingRequiredCount > cfg.MissingRequiredRetryCount {
-				return fmt.Errorf("required checks not found: %q", sortStrings(lo.Keys(requiredNotFound)))
-			}
-			action.Infof("Required checks not found: %q, continuing another %d times before failing", lo.Keys(requiredNotFound), cfg.MissingRequiredRetryCount-missingRequiredCount)
-			action.Infof("Waiting %s before next check", cfg.PollFrequency)
-			time.Sleep(cfg.PollFrequency)
-			continue
+		// The published check run is not waited on.
+		checks = lo.Reject(checks, func(item Check, _ int) bool { return publisher.isSelf(item) })
+		d := Evaluate(Snapshot{Checks: checks, Now: now, FirstSeen: firstSeen}, rules, env)
+		for _, c := range d.Skipped {
+			reporter.Infof("Skipping check: %q", c.GetName())
 		}
-
-		// Find failed conclusions, and fail early if there is.
-		failed := lo.Filter(toCheck, func(item *github.CheckRun, _ int) bool {
-			return slices.Contains(failedConclusions, item.GetConclusion())
-		})
-
-		if len(failed) > 0 {
-			return fmt.Errorf("required checks failed: %q", checkNames(failed))
+		for _, m := range d.Suspicious {
+			if key := checkKey(m.Check); !suspicious[key] {
+				suspicious[key] = true
+				reportSuspicious(reporter, m.Rule, m.Check)
+			}
 		}
 
-		// Wait until all statuses are completed.
-		notCompleted := lo.Filter(toCheck, func(item *github.CheckRun, _ int) bool {
-			return item.GetStatus() != StatusCompleted
-		})
+		report.decision, report.now = d, now
+		publisher.progress(ctx, report)
 
-		// Break out of the loop if all checks are completed.
-		if len(notCompleted) == 0 {
-			action.Infof("All checks completed")
-			break
+		switch d.Outcome {
+		case OutcomeMissing:
+			// Retry in case the workflow is still being created, then fail as there will not be a successful check.
+			missingRequiredCount++
+			if missingRequiredCount > cfg.MissingRequiredRetryCount {
+				for _, r := range d.Missing {
+					if r.Description != "" {
+						reporter.Infof("Required check not found %q: %s", r, r.Description)
+					}
+					annotateMissing(reporter, r)
+				}
+				return &MissingChecksError{Missing: missingPatterns(d)}
+			}
+			if timedOut() {
+				return &TimeoutError{
+					Timeout: cfg.Timeout,
+					Pending: pendingChecks(d.Waiting, firstSeen, now),
+					Missing: sortStrings(ruleNames(d.Missing)),
+				}
+			}
+			reporter.Infof("Required checks not found: %q, continuing another %d times before failing", ruleNames(d.Missing), cfg.MissingRequiredRetryCount-missingRequiredCount)
+		case OutcomeFailed:
+			for _, m := range d.Failed {
+				annotateFailed(reporter, m)
+			}
+			return &FailedChecksError{Failed: failedChecks(d.Failed)}
+		case OutcomeTimedOut:
+			return &TimeoutError{Pending: pendingChecks(d.TimedOut, firstSeen, now)}
+		case OutcomePassed:
+			reporter.Infof("All checks completed")
+			return nil
+		case OutcomeWaiting:
+			if timedOut() {
+				return &TimeoutError{Timeout: cfg.Timeout, Pending: pendingChecks(d.Waiting, firstSeen, now)}
+			}
+			reporter.Infof("Not all checks completed: %q", matchNames(d.Waiting))
 		}
 
 		// sleep and try again.
-		action.Infof("Not all checks completed: %q", checkNames(notCompleted))
-		action.Infof("Waiting %s before next check", cfg.PollFrequency)
-		time.Sleep(cfg.PollFrequency)
+		reporter.Infof("Waiting %s before next check", waitDuration())
+		if err := sleep(ctx, clock, waitDuration()); err != nil {
+			return err
+		}
 	}
-	return nil
 }
 
-func getConditionalPathPatterns(ctx context.Context, ghCtx *githubactions.GitHubContext, cfg *Config, action *githubactions.Action, pr PRClient) ([]string, error) {
+// getConditionalPathPatterns returns the patterns of the path globs that match the changed files, recording which
+// globs matched in the report.
+func getConditionalPathPatterns(ctx context.Context, env Environment, cfg *Config, reporter Reporter, pr PRClient, report *summary) ([]Pattern, error) {
 	if len(cfg.ConditionalPathWorkflowPatterns) == 0 {
 		return nil, nil
 	}
 
-	if ghCtx.EventName == "merge_group" {
-		action.Debugf("Skipping path globs for merge_group")
+	fileNames, err := ListChangedFiles(ctx, env, cfg, reporter, pr)
+	if errors.Is(err, errNoChangedFiles) {
+		reporter.Warningf("Skipping path globs, so their conditional checks are not required: %s", err)
+		report.pathsSkipped = fmt.Sprintf("Skipped path globs: %s", err)
 		return nil, nil
 	}
-
-	fileNames, err := listPullRequestFiles(ctx, pr)
 	if err != nil {
 		return nil, err
 	}
-	matched := lo.Filter(lo.Keys(cfg.ConditionalPathWorkflowPatterns), func(pathGlob string, _ int) bool {
-		for _, name := range fileNames {
-			if matched, _ := doublestar.Match(pathGlob, name); matched {
-				action.Infof("Matched path glob [%s] with file: %s", pathGlob, name)
-				action.Infof("Adding checks to required: %q", cfg.ConditionalPathWorkflowPatterns[pathGlob])
-				return true
-			}
+
+	var patterns []Pattern
+	report.paths = MatchPaths(cfg.ConditionalPathWorkflowPatterns, fileNames)
+	for _, match := range report.paths {
+		if match.Matched() {
+			reporter.Infof("Matched path glob [%s] with file: %s", match, match.File)
+			reporter.Infof("Adding checks to required: %q", PatternNames(match.Patterns))
+			patterns = append(patterns, match.Patterns...)
 		}
-		return false
-	})
-	return lo.Flatten(lo.Values(lo.PickByKeys(cfg.ConditionalPathWorkflowPatterns, matched))), nil
+	}
+	return patterns, nil
 }
 
 type PRClient interface {
 	ListChecks(ctx context.Context, sha string, options *github.ListCheckRunsOptions) ([]*github.CheckRun, error)
+	ListStatuses(ctx context.Context, sha string, options *github.ListOptions) ([]*github.RepoStatus, error)
 	ListFiles(ctx context.Context, options *github.ListOptions) ([]*github.CommitFile, error)
 }
 
-func checkNames(checks []*github.CheckRun) []string {
-	names := make([]string, 0, len(checks))
-	for _, c := range checks {
-		names = append(names, c.GetName())
+// ListChecks lists both the check runs and the commit statuses for the sha.
+func ListChecks(ctx context.Context, pr PRClient, sha string) ([]Check, error) {
+	runs, err := pr.ListChecks(ctx, sha, nil)
+	if err != nil {
+		return nil, err
 	}
-	return names
+	statuses, err := pr.ListStatuses(ctx, sha, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(checkRunsToChecks(runs), statusesToChecks(statuses)...), nil
 }
 
-func listPullRequestFiles(ctx context.Context, pr PRClient) ([]string, error) {
-	files, err := pr.ListFiles(ctx, nil)
-	if isNotFoundError(err) {
-		return nil, nil
+// reportSuspicious warns about a check that matches a pattern but was reported by an app that is not allowed to
+// satisfy it, which could be an attempt to spoof the check.
+func reportSuspicious(reporter Reporter, rule *Rule, c Check) {
+	app := checkApp(c)
+	reporter.Warningf("Ignoring suspicious check %q from app %q: pattern %q only allows apps %q", c.GetName(), app, rule, rule.Apps)
+}
+
+// annotateFailed reports the failed check with its conclusion, output and details URL.
+func annotateFailed(reporter Reporter, m Match) {
+	lines := []string{fmt.Sprintf("Required check %q concluded %s", m.GetName(), m.GetConclusion())}
+	if title := m.GetOutput().GetTitle(); title != "" {
+		lines = append(lines, title)
 	}
-	if err != nil {
-		return nil, err
+	if summary := m.GetOutput().GetSummary(); summary != "" {
+		lines = append(lines, summary)
+	}
+	if url := m.GetDetailsURL(); url != "" {
+		lines = append(lines, "Details: "+url)
+	}
+	addErrorAnnotation(reporter, m.GetName(), strings.Join(lines, "\n"))
+}
+
+// annotateMissing reports the required pattern that did not match any checks.
+func annotateMissing(reporter Reporter, r *Rule) {
+	msg := fmt.Sprintf("Required check not found for pattern %q", r)
+	if r.MinCount > 1 {
+		msg = fmt.Sprintf("Fewer than %d required checks found for pattern %q", r.MinCount, r)
+	}
+	if r.Description != "" {
+		msg += ": " + r.Description
+	}
+	addErrorAnnotation(reporter, r.String(), msg)
+}
+
+func checkKey(c Check) string {
+	return fmt.Sprintf("%s/%d/%s", c.Source, c.GetID(), c.GetName())
+}
+
+func checkApp(c Check) string {
+	if slug := c.GetApp().GetSlug(); slug != "" {
+		return slug
+	}
+	if id := c.GetApp().GetID(); id != 0 {
+		return strconv.FormatInt(id, 10)
+	}
+	return ""
+}
+
+// waitingSince is when the check started, was created, or was first seen if neither are known.
+func waitingSince(c Check, firstSeen map[string]time.Time) time.Time {
+	if c.StartedAt != nil {
+		return c.StartedAt.Time
+	}
+	if createdAt := c.GetCheckSuite().GetCreatedAt(); !createdAt.IsZero() {
+		return createdAt.Time
 	}
-	fileNames := lo.Map(files, func(item *github.CommitFile, _ int) string { return item.GetFilename() })
-	return fileNames, nil
+	return firstSeen[checkKey(c)]
+}
+
+func pendingChecks(matches []Match, firstSeen map[string]time.Time, now time.Time) []PendingCheck {
+	return lo.Map(matches, func(item Match, _ int) PendingCheck {
+		return PendingCheck{
+			Name:    item.GetName(),
+			Pattern: item.Rule.String(),
+			Status:  item.GetStatus(),
+			Waiting: now.Sub(waitingSince(item.Check, firstSeen)),
+			Timeout: item.Rule.Timeout,
+		}
+	})
+}
+
+func missingPatterns(d Decision) []MissingPattern {
+	var missing []MissingPattern
+	for _, p := range d.Patterns {
+		if p.Outcome == OutcomeMissing {
+			missing = append(missing, MissingPattern{
+				Pattern:     p.Rule.String(),
+				Description: p.Rule.Description,
+				Found:       len(p.Matches),
+				MinCount:    max(p.Rule.MinCount, 1),
+			})
+		}
+	}
+	return missing
+}
+
+func failedChecks(matches []Match) []FailedCheck {
+	return lo.Map(matches, func(item Match, _ int) FailedCheck {
+		return FailedCheck{
+			Name:       item.GetName(),
+			Pattern:    item.Rule.String(),
+			Conclusion: item.GetConclusion(),
+			DetailsURL: item.GetDetailsURL(),
+		}
+	})
+}
+
+func matchNames(matches []Match) []string {
+	return lo.Map(matches, func(item Match, _ int) string { return item.GetName() })
+}
+
+func ruleNames(rules []*Rule) []string {
+	return lo.Map(rules, func(item *Rule, _ int) string { return item.String() })
+}
+
+func checkNames(checks []Check) []string {
+	names := make([]string, 0, len(checks))
+	for _, c := range checks {
+		names = append(names, c.GetName())
+	}
+	return names
 }
 
 func isNotFoundError(err error) bool {
@@ -209,31 +382,162 @@ const (
 	StatusPending    = "pending"
 )
 
-var failedConclusions = []string{ConclusionFailure, ConclusionCancelled, ConclusionTimedOut}
+// Rule is a compiled Pattern that matches checks by name, optionally scoped to a single source with a "check:" or
+// "status:" prefix. Apps restricts the GitHub Apps, by slug or ID, that are allowed to satisfy the rule, and Policy
+// is the effective conclusion policy.
+type Rule struct {
+	Pattern
+	Source string
+	Regexp *regexp.Regexp
+	Apps   []string
+	Policy ConclusionPolicy
+}
+
+func NewRule(pattern Pattern) (*Rule, error) {
+	source, expr := parseSource(pattern.Pattern)
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Rule{
+		Pattern: pattern,
+		Source:  source,
+		Regexp:  re,
+		Apps:    slices.Clone(pattern.App),
+		Policy:  DefaultConclusionPolicy.Merge(pattern.ConclusionPolicy),
+	}, nil
+}
+
+func parseSource(pattern string) (string, string) {
+	for _, source := range []string{SourceCheckRun, SourceStatus} {
+		if expr, ok := strings.CutPrefix(pattern, source+":"); ok {
+			return source, expr
+		}
+	}
+	return "", pattern
+}
+
+func (r *Rule) Match(c Check) bool {
+	if r.Source != "" && r.Source != c.Source {
+		return false
+	}
+	return r.Regexp.MatchString(c.GetName())
+}
+
+// AllowsApp reports whether the check was reported by one of the rule's allowed apps.
+// Commit statuses are not reported by an app, so never satisfy a rule restricted to apps.
+func (r *Rule) AllowsApp(c Check) bool {
+	if len(r.Apps) == 0 {
+		return true
+	}
+	app := c.GetApp()
+	if app == nil {
+		return false
+	}
+	id := strconv.FormatInt(app.GetID(), 10)
+	return lo.SomeBy(r.Apps, func(allowed string) bool {
+		return allowed == app.GetSlug() || allowed == id
+	})
+}
 
-type Ruleset []*regexp.Regexp
+// Missing reports whether fewer checks than required have matched the rule.
+func (r *Rule) Missing(count int) bool {
+	if r.Optional {
+		return false
+	}
+	return count < max(r.MinCount, 1)
+}
 
-func NewRuleset(patterns []string) (Ruleset, error) {
-	r := make([]*regexp.Regexp, 0, len(patterns))
+// Verdict returns the verdict for the conclusion of a completed check. Allowed conclusions take precedence over the
+// conclusion policy.
+func (r *Rule) Verdict(c Check) Verdict {
+	if len(r.AllowedConclusions) > 0 {
+		if slices.Contains(r.AllowedConclusions, c.GetConclusion()) {
+			return VerdictPass
+		}
+		return VerdictFail
+	}
+	return r.Policy.Verdict(c.GetConclusion())
+}
+
+// Failed reports whether the check has completed with a conclusion the rule fails.
+func (r *Rule) Failed(c Check) bool {
+	return c.GetStatus() == StatusCompleted && r.Verdict(c) == VerdictFail
+}
+
+// Waiting reports whether the check has not completed, or has completed with a conclusion the rule waits on.
+func (r *Rule) Waiting(c Check) bool {
+	return c.GetStatus() != StatusCompleted || r.Verdict(c) == VerdictWait
+}
+
+// TimedOut reports whether the check has been waiting since longer than the rule's timeout.
+func (r *Rule) TimedOut(c Check, since, now time.Time) bool {
+	if r.Timeout == 0 || !r.Waiting(c) {
+		return false
+	}
+	return now.Sub(since) > r.Timeout
+}
+
+func (r *Rule) String() string {
+	return r.Pattern.Pattern
+}
+
+type Ruleset []*Rule
+
+func NewRuleset(patterns []Pattern) (Ruleset, error) {
+	r := make([]*Rule, 0, len(patterns))
 	for _, p := range patterns {
-		re, err := regexp.Compile(p)
+		rule, err := NewRule(p)
 		if err != nil {
 			return nil, err
 		}
-		r = append(r, re)
+		r = append(r, rule)
 	}
 	return r, nil
 }
 
-func (r Ruleset) First(test string) *regexp.Regexp {
-	for _, re := range r {
-		if re.MatchString(test) {
-			return re
+// Ruleset creates the rules of the required patterns and the extra patterns, e.g. those of the matched path globs, with
+// the check apps and the global conclusion policy applied. Duplicate patterns are merged with the strictest options.
+func (c *Config) Ruleset(extra []Pattern) (Ruleset, error) {
+	rules, err := NewRuleset(UniquePatterns(append(slices.Clone(c.RequiredWorkflowPatterns), extra...), c.ConclusionPolicy))
+	if err != nil {
+		return nil, err
+	}
+	rules.SetApps(c.CheckApps)
+	rules.SetConclusionPolicy(c.ConclusionPolicy)
+	return rules, nil
+}
+
+// SetApps restricts each rule to the apps configured for its pattern.
+func (r Ruleset) SetApps(apps map[string][]string) {
+	for _, rule := range r {
+		rule.Apps = lo.Uniq(append(rule.Apps, apps[rule.String()]...))
+	}
+}
+
+// SetConclusionPolicy overrides the default conclusion policy of each rule with the global policy, keeping any
+// per-pattern policy.
+func (r Ruleset) SetConclusionPolicy(policy ConclusionPolicy) {
+	for _, rule := range r {
+		rule.Policy = DefaultConclusionPolicy.Merge(policy).Merge(rule.Pattern.ConclusionPolicy)
+	}
+}
+
+func (r Ruleset) First(c Check) *Rule {
+	for _, rule := range r {
+		if rule.Match(c) {
+			return rule
 		}
 	}
 	return nil
 }
 
+// Match is a check matched by a rule.
+type Match struct {
+	Check
+	Rule *Rule
+}
+
 func sortStrings(slice []string) []string {
 	sort.Strings(slice)
 	return slice
@@ -3,16 +3,23 @@ package reqcheck
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-github/v61/github"
 	"github.com/sethvargo/go-githubactions"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"slices"
 
+	"github.com/roryq/required-checks/pkg/reqcheck/outputs"
 	"github.com/roryq/required-checks/pkg/xassert"
 )
 
@@ -20,6 +27,7 @@ func TestRun(t *testing.T) {
 	testCases := map[string]struct {
 		config              *Config
 		checkRuns           []*github.CheckRun
+		statuses            []*github.RepoStatus
 		prFiles             []*github.CommitFile
 		listChecksError     error
 		assertError         assert.ErrorAssertionFunc
@@ -28,7 +36,7 @@ func TestRun(t *testing.T) {
 	}{
 		"all required checks pass": {
 			config: &Config{
-				RequiredWorkflowPatterns:  []string{"required-check-1", "required-check-2"},
+				RequiredWorkflowPatterns:  NewPatterns("required-check-1", "required-check-2"),
 				MissingRequiredRetryCount: 1,
 				TargetSHA:                 "test-sha",
 			},
@@ -46,7 +54,7 @@ func TestRun(t *testing.T) {
 			},
 			assertError: assert.NoError,
 			expectedOutputLines: []string{
-				"Waiting 1ms before initial check",
+				"Waiting 15s before initial check",
 				"Got 2 checks",
 				`Checks: ["required-check-1" "required-check-2"]`,
 				"All checks completed",
@@ -55,7 +63,7 @@ func TestRun(t *testing.T) {
 		},
 		"required check fails": {
 			config: &Config{
-				RequiredWorkflowPatterns:  []string{"required-check-1", "required-check-2"},
+				RequiredWorkflowPatterns:  NewPatterns("required-check-1", "required-check-2"),
 				MissingRequiredRetryCount: 1,
 				TargetSHA:                 "test-sha",
 			},
@@ -76,7 +84,7 @@ func TestRun(t *testing.T) {
 		},
 		"required check missing": {
 			config: &Config{
-				RequiredWorkflowPatterns:  []string{"required-check-1", "missing-check"},
+				RequiredWorkflowPatterns:  NewPatterns("required-check-1", "missing-check"),
 				MissingRequiredRetryCount: 0,
 				TargetSHA:                 "test-sha",
 			},
@@ -89,7 +97,7 @@ func TestRun(t *testing.T) {
 			},
 			assertError: xassert.ErrorContains(`required checks not found: ["missing-check"]`),
 			expectedOutputLines: []string{
-				"Waiting 1ms before initial check",
+				"Waiting 15s before initial check",
 				"Got 1 checks",
 				`Checks: ["required-check-1"]`,
 			},
@@ -97,7 +105,7 @@ func TestRun(t *testing.T) {
 		},
 		"unexpected EOF error": {
 			config: &Config{
-				RequiredWorkflowPatterns:  []string{"required-check-1"},
+				RequiredWorkflowPatterns:  NewPatterns("required-check-1"),
 				MissingRequiredRetryCount: 1,
 				TargetSHA:                 "test-sha",
 			},
@@ -111,7 +119,7 @@ func TestRun(t *testing.T) {
 			},
 			assertError: assert.NoError,
 			expectedOutputLines: []string{
-				"Waiting 1ms before initial check",
+				"Waiting 15s before initial check",
 				"Unexpected EOF, retrying...",
 				"Got 1 checks",
 				`Checks: ["required-check-1"]`,
@@ -121,7 +129,7 @@ func TestRun(t *testing.T) {
 		},
 		"check not completed": {
 			config: &Config{
-				RequiredWorkflowPatterns:  []string{"required-check-1"},
+				RequiredWorkflowPatterns:  NewPatterns("required-check-1"),
 				MissingRequiredRetryCount: 1,
 				TargetSHA:                 "test-sha",
 			},
@@ -138,11 +146,11 @@ func TestRun(t *testing.T) {
 			},
 			assertError: assert.NoError,
 			expectedOutputLines: []string{
-				`Waiting 1ms before initial check`,
+				`Waiting 15s before initial check`,
 				`Got 1 checks`,
 				`Checks: ["required-check-1"]`,
 				`Not all checks completed: ["required-check-1"]`,
-				`Waiting 1ms before next check`,
+				`Waiting 30s before next check`,
 				`Got 1 checks`,
 				`Checks: ["required-check-1"]`,
 				`All checks completed`,
@@ -151,7 +159,7 @@ func TestRun(t *testing.T) {
 		},
 		"path based checklist is activated": {
 			config: &Config{
-				ConditionalPathWorkflowPatterns: map[string][]string{"main.go": {"go unit tests"}},
+				ConditionalPathWorkflowPatterns: map[string]PathRule{"main.go": {Patterns: NewPatterns("go unit tests")}},
 			},
 			checkRuns: []*github.CheckRun{
 				{
@@ -174,7 +182,7 @@ func TestRun(t *testing.T) {
 				`Got 1 checks`,
 				`Checks: ["go unit tests"]`,
 				`Not all checks completed: ["go unit tests"]`,
-				`Waiting 1ms before next check`,
+				`Waiting 30s before next check`,
 				`Got 1 checks`,
 				`Checks: ["go unit tests"]`,
 				`All checks completed`,
@@ -182,7 +190,7 @@ func TestRun(t *testing.T) {
 		},
 		"path based checklist fails if missing": {
 			config: &Config{
-				ConditionalPathWorkflowPatterns: map[string][]string{"main.go": {"go unit tests"}},
+				ConditionalPathWorkflowPatterns: map[string]PathRule{"main.go": {Patterns: NewPatterns("go unit tests")}},
 			},
 			checkRuns: []*github.CheckRun{},
 			prFiles: []*github.CommitFile{
@@ -197,8 +205,8 @@ func TestRun(t *testing.T) {
 		},
 		"path based checklist combines with required checks": {
 			config: &Config{
-				ConditionalPathWorkflowPatterns: map[string][]string{"main.go": {"go unit tests"}},
-				RequiredWorkflowPatterns:        []string{"required-check"},
+				ConditionalPathWorkflowPatterns: map[string]PathRule{"main.go": {Patterns: NewPatterns("go unit tests")}},
+				RequiredWorkflowPatterns:        NewPatterns("required-check"),
 			},
 			checkRuns: []*github.CheckRun{
 				{
@@ -223,22 +231,280 @@ func TestRun(t *testing.T) {
 				`All checks completed`,
 			},
 		},
+		"commit status satisfies pattern": {
+			config: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("required-check-1", "ci/jenkins"),
+				MissingRequiredRetryCount: 0,
+				TargetSHA:                 "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("required-check-1"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+				},
+			},
+			statuses: []*github.RepoStatus{
+				{Context: github.String("ci/jenkins"), State: github.String(StateSuccess)},
+			},
+			assertError: assert.NoError,
+			expectedOutputLines: []string{
+				"Got 2 checks",
+				`Checks: ["required-check-1" "ci/jenkins"]`,
+				"All checks completed",
+			},
+		},
+		"commit status fails": {
+			config: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("status:ci/.*"),
+				MissingRequiredRetryCount: 0,
+				TargetSHA:                 "test-sha",
+			},
+			statuses: []*github.RepoStatus{
+				{Context: github.String("ci/circleci"), State: github.String(StateError)},
+			},
+			assertError: xassert.ErrorContains(`required checks failed: ["ci/circleci"]`),
+		},
+		"source prefix does not match other source": {
+			config: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("check:ci/jenkins"),
+				MissingRequiredRetryCount: 0,
+				TargetSHA:                 "test-sha",
+			},
+			statuses: []*github.RepoStatus{
+				{Context: github.String("ci/jenkins"), State: github.String(StateSuccess)},
+			},
+			assertError: xassert.ErrorContains(`required checks not found: ["check:ci/jenkins"]`),
+		},
+		"check from disallowed app is ignored": {
+			config: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("unit-tests"),
+				CheckApps:                 map[string][]string{"unit-tests": {"github-actions"}},
+				MissingRequiredRetryCount: 0,
+				TargetSHA:                 "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("unit-tests"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+					App:        &github.App{ID: github.Int64(42), Slug: github.String("spoofer")},
+				},
+			},
+			assertError: xassert.ErrorContains(`required checks not found: ["unit-tests"]`),
+			expectedOutputLines: []string{
+				`Ignoring suspicious check "unit-tests" from app "spoofer": pattern "unit-tests" only allows apps ["github-actions"]`,
+			},
+		},
+		"check from allowed app id passes": {
+			config: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("unit-tests"),
+				CheckApps:                 map[string][]string{"unit-tests": {"15368"}},
+				MissingRequiredRetryCount: 0,
+				TargetSHA:                 "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("unit-tests"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionFailure),
+					App:        &github.App{ID: github.Int64(42), Slug: github.String("spoofer")},
+				},
+				{
+					Name:       github.String("unit-tests"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+					App:        &github.App{ID: github.Int64(15368), Slug: github.String("github-actions")},
+				},
+			},
+			assertError: assert.NoError,
+			expectedOutputLines: []string{
+				`Ignoring suspicious check "unit-tests" from app "spoofer"`,
+				"All checks completed",
+			},
+		},
+		"optional check may be missing": {
+			config: &Config{
+				RequiredWorkflowPatterns: []Pattern{
+					{Pattern: "required-check-1"},
+					{Pattern: "optional-check", Optional: true},
+				},
+				TargetSHA: "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("required-check-1"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+				},
+			},
+			assertError: assert.NoError,
+		},
+		"optional check must pass if present": {
+			config: &Config{
+				RequiredWorkflowPatterns: []Pattern{{Pattern: "optional-check", Optional: true}},
+				TargetSHA:                "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("optional-check"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionFailure),
+				},
+			},
+			assertError: xassert.ErrorContains(`required checks failed: ["optional-check"]`),
+		},
+		"min count not reached": {
+			config: &Config{
+				RequiredWorkflowPatterns: []Pattern{{Pattern: "unit-tests", MinCount: 2, Description: "one per go version"}},
+				TargetSHA:                "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("unit-tests (1.23)"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+				},
+			},
+			assertError: xassert.ErrorContains(`required checks not found: ["unit-tests"]`),
+			expectedOutputLines: []string{
+				`Required check not found "unit-tests": one per go version`,
+			},
+		},
+		"allowed conclusions refuse skipped": {
+			config: &Config{
+				RequiredWorkflowPatterns: []Pattern{{Pattern: "security-scan", AllowedConclusions: []string{ConclusionSuccess}}},
+				TargetSHA:                "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("security-scan"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSkipped),
+				},
+			},
+			assertError: xassert.ErrorContains(`required checks failed: ["security-scan"]`),
+		},
+		"check exceeds rule timeout": {
+			config: &Config{
+				RequiredWorkflowPatterns: []Pattern{{Pattern: "slow-check", Timeout: time.Minute}},
+				TargetSHA:                "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:      github.String("slow-check"),
+					Status:    github.String(StatusQueued),
+					StartedAt: &github.Timestamp{Time: testStart.Add(-time.Hour)},
+				},
+			},
+			assertError: xassert.ErrorContains(`required checks timed out: "slow-check" queued for 1h0m`),
+		},
+		"global policy fails action required": {
+			config: &Config{
+				RequiredWorkflowPatterns: NewPatterns("deploy-approval"),
+				ConclusionPolicy:         ConclusionPolicy{ConclusionActionRequired: VerdictFail},
+				TargetSHA:                "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("deploy-approval"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionActionRequired),
+				},
+			},
+			assertError: xassert.ErrorContains(`required checks failed: ["deploy-approval"]`),
+		},
+		"pattern policy overrides global policy": {
+			config: &Config{
+				RequiredWorkflowPatterns: []Pattern{
+					{Pattern: "lint"},
+					{Pattern: "security-scan", ConclusionPolicy: ConclusionPolicy{ConclusionSkipped: VerdictFail}},
+				},
+				ConclusionPolicy: ConclusionPolicy{ConclusionSkipped: VerdictPass},
+				TargetSHA:        "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("lint"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSkipped),
+				},
+				{
+					Name:       github.String("security-scan"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSkipped),
+				},
+			},
+			assertError: xassert.ErrorContains(`required checks failed: ["security-scan"]`),
+		},
+		"stale check waits for re-run": {
+			config: &Config{
+				RequiredWorkflowPatterns: NewPatterns("required-check-1"),
+				ConclusionPolicy:         ConclusionPolicy{ConclusionStale: VerdictWait},
+				TargetSHA:                "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("required-check-1"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionStale),
+				},
+				{
+					Name:       github.String("required-check-1"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+				},
+			},
+			progressiveChecks: true,
+			assertError:       assert.NoError,
+			expectedOutputLines: []string{
+				`Not all checks completed: ["required-check-1"]`,
+				`All checks completed`,
+			},
+		},
+		"global timeout while checks in progress": {
+			config: &Config{
+				RequiredWorkflowPatterns: NewPatterns("required-check-1"),
+				Timeout:                  2 * time.Minute,
+				TargetSHA:                "test-sha",
+			},
+			checkRuns: []*github.CheckRun{
+				{
+					Name:   github.String("required-check-1"),
+					Status: github.String(StatusInProgress),
+				},
+			},
+			assertError: xassert.ErrorContains(`required checks timed out after 2m0s: "required-check-1" in_progress for 1m45s`),
+			expectedOutputLines: []string{
+				"Timing out after 2m0s",
+				"Waiting 15s before next check",
+			},
+		},
+		"global timeout while checks missing": {
+			config: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("missing-check"),
+				MissingRequiredRetryCount: 1000,
+				Timeout:                   2 * time.Minute,
+				TargetSHA:                 "test-sha",
+			},
+			assertError: xassert.ErrorContains(`required checks timed out after 2m0s: not found ["missing-check"]`),
+		},
 	}
 
 	for name, tc := range testCases {
 		// set default freq
-		tc.config.InitialDelay = time.Millisecond
-		tc.config.PollFrequency = time.Millisecond
+		tc.config.InitialDelay = 15 * time.Second
+		tc.config.PollFrequency = 30 * time.Second
 
 		t.Run(name, func(t *testing.T) {
 			// Setup
 			action, output := setupAction("pull-request.opened")
 
 			// Create a mock pullrequest client
-			mockPRClient := setupMockPRClient(tc.checkRuns, tc.listChecksError, tc.progressiveChecks, tc.prFiles)
+			mockPRClient := setupMockPRClient(tc.checkRuns, tc.listChecksError, tc.progressiveChecks, tc.prFiles, tc.statuses)
 
 			// Run the function
-			err := run(context.Background(), tc.config, action, mockPRClient)
+			err := run(context.Background(), tc.config, setupEnvironment(t, action), action, mockPRClient, newFakeClock())
 
 			// Check error
 			tc.assertError(t, err)
@@ -253,22 +519,114 @@ func TestRun(t *testing.T) {
 	}
 }
 
+var testStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeClock is a Clock where waiting advances the time immediately.
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: testStart}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
 // mockPullRequestClient is a mock implementation of the pullrequest.Client
 type mockPullRequestClient struct {
-	ListChecksFunc func(ctx context.Context, sha string, options *github.ListCheckRunsOptions) ([]*github.CheckRun, error)
-	ListFilesFunc  func(ctx context.Context, options *github.ListOptions) ([]*github.CommitFile, error)
+	ListChecksFunc   func(ctx context.Context, sha string, options *github.ListCheckRunsOptions) ([]*github.CheckRun, error)
+	ListStatusesFunc func(ctx context.Context, sha string, options *github.ListOptions) ([]*github.RepoStatus, error)
+	ListFilesFunc    func(ctx context.Context, options *github.ListOptions) ([]*github.CommitFile, error)
 }
 
 func (m *mockPullRequestClient) ListChecks(ctx context.Context, sha string, options *github.ListCheckRunsOptions) ([]*github.CheckRun, error) {
 	return m.ListChecksFunc(ctx, sha, options)
 }
 
+func (m *mockPullRequestClient) ListStatuses(ctx context.Context, sha string, options *github.ListOptions) ([]*github.RepoStatus, error) {
+	return m.ListStatusesFunc(ctx, sha, options)
+}
+
 func (m *mockPullRequestClient) ListFiles(ctx context.Context, options *github.ListOptions) ([]*github.CommitFile, error) {
 	return m.ListFilesFunc(ctx, options)
 }
 
+// mockCheckRunClient is a mock PR client that records the check runs it creates and updates.
+type mockCheckRunClient struct {
+	*mockPullRequestClient
+	Created []github.CreateCheckRunOptions
+	Updated []github.UpdateCheckRunOptions
+}
+
+func (m *mockCheckRunClient) CreateCheckRun(_ context.Context, opts github.CreateCheckRunOptions) (*github.CheckRun, error) {
+	m.Created = append(m.Created, opts)
+	return &github.CheckRun{ID: github.Int64(99), Name: github.String(opts.Name)}, nil
+}
+
+func (m *mockCheckRunClient) UpdateCheckRun(_ context.Context, _ int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, error) {
+	m.Updated = append(m.Updated, opts)
+	return &github.CheckRun{ID: github.Int64(99), Name: github.String(opts.Name)}, nil
+}
+
+// mockCommentClient is a mock PR client that records the comments it creates and edits.
+type mockCommentClient struct {
+	*mockPullRequestClient
+	Comments []*github.IssueComment
+	Created  []string
+	Edited   []int64
+}
+
+func (m *mockCommentClient) CommentAuthor(_ context.Context) (string, error) {
+	return "github-actions[bot]", nil
+}
+
+func (m *mockCommentClient) ListComments(_ context.Context) ([]*github.IssueComment, error) {
+	return m.Comments, nil
+}
+
+func (m *mockCommentClient) CreateComment(_ context.Context, body string) error {
+	m.Created = append(m.Created, body)
+	m.Comments = append(m.Comments, &github.IssueComment{
+		ID:   github.Int64(int64(len(m.Comments) + 1)),
+		Body: github.String(body),
+		User: &github.User{Login: github.String("github-actions[bot]")},
+	})
+	return nil
+}
+
+func (m *mockCommentClient) EditComment(_ context.Context, id int64, body string) error {
+	m.Edited = append(m.Edited, id)
+	for _, c := range m.Comments {
+		if c.GetID() == id {
+			c.Body = github.String(body)
+		}
+	}
+	return nil
+}
+
+// mockCompareClient is a mock PR client that records the commits it compares.
+type mockCompareClient struct {
+	*mockPullRequestClient
+	Files    []*github.CommitFile
+	Compared [][2]string
+}
+
+func (m *mockCompareClient) CompareFiles(_ context.Context, base, head string) ([]*github.CommitFile, error) {
+	m.Compared = append(m.Compared, [2]string{base, head})
+	return m.Files, nil
+}
+
 // setupMockPRClient creates a mock PR client with the appropriate behavior for the test case
-func setupMockPRClient(checkRuns []*github.CheckRun, listChecksError error, progressiveChecks bool, prFiles []*github.CommitFile) *mockPullRequestClient {
+func setupMockPRClient(checkRuns []*github.CheckRun, listChecksError error, progressiveChecks bool, prFiles []*github.CommitFile, statuses []*github.RepoStatus) *mockPullRequestClient {
 	// Set up a counter for the number of API calls
 	callCount := 0
 
@@ -292,6 +650,10 @@ func setupMockPRClient(checkRuns []*github.CheckRun, listChecksError error, prog
 			return runs, nil
 		},
 
+		ListStatusesFunc: func(ctx context.Context, sha string, options *github.ListOptions) ([]*github.RepoStatus, error) {
+			return statuses, nil
+		},
+
 		ListFilesFunc: func(ctx context.Context, options *github.ListOptions) ([]*github.CommitFile, error) {
 			return prFiles, nil
 		},
@@ -299,13 +661,21 @@ func setupMockPRClient(checkRuns []*github.CheckRun, listChecksError error, prog
 }
 
 func setupAction(event string, values ...string) (*githubactions.Action, *bytes.Buffer) {
+	return setupActionWithEnv(event, nil, values...)
+}
+
+// setupActionWithEnv is the same as setupAction with additional environment variables.
+func setupActionWithEnv(event string, env map[string]string, values ...string) (*githubactions.Action, *bytes.Buffer) {
 	envMap := map[string]string{
 		"GITHUB_EVENT_PATH":   fmt.Sprintf("../../test/events/%s.json", event),
 		"GITHUB_STEP_SUMMARY": "/dev/null",
+		"GITHUB_OUTPUT":       "/dev/null",
 		"GITHUB_REPOSITORY":   "RoryQ/required-checks",
 		"GITHUB_RUN_ID":       "12345",
 	}
 
+	maps.Copy(envMap, env)
+
 	for keyValue := range slices.Chunk(values, 2) {
 		envMap["INPUT_"+keyValue[0]] = keyValue[1]
 	}
@@ -322,3 +692,667 @@ func setupAction(event string, values ...string) (*githubactions.Action, *bytes.
 	)
 	return action, b
 }
+
+func TestRun_TimeoutError(t *testing.T) {
+	action, _ := setupAction("pull-request.opened")
+	cfg := &Config{
+		RequiredWorkflowPatterns: []Pattern{{Pattern: "slow-check", Timeout: time.Minute}},
+		PollFrequency:            30 * time.Second,
+		TargetSHA:                "test-sha",
+	}
+	checkRuns := []*github.CheckRun{
+		{
+			Name:      github.String("slow-check"),
+			Status:    github.String(StatusInProgress),
+			StartedAt: &github.Timestamp{Time: testStart.Add(-time.Hour)},
+		},
+	}
+
+	err := run(context.Background(), cfg, setupEnvironment(t, action), action, setupMockPRClient(checkRuns, nil, false, nil, nil), newFakeClock())
+
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Zero(t, timeoutErr.Timeout)
+	require.Len(t, timeoutErr.Pending, 1)
+	assert.Equal(t, "slow-check", timeoutErr.Pending[0].Name)
+	assert.Equal(t, StatusInProgress, timeoutErr.Pending[0].Status)
+	assert.Equal(t, time.Minute, timeoutErr.Pending[0].Timeout)
+	assert.GreaterOrEqual(t, timeoutErr.Pending[0].Waiting, time.Hour)
+}
+
+func TestRun_UnexpectedEOFTimeout(t *testing.T) {
+	action, _ := setupAction("pull-request.opened")
+	cfg := &Config{
+		RequiredWorkflowPatterns: NewPatterns("required-check-1"),
+		PollFrequency:            30 * time.Second,
+		Timeout:                  time.Minute,
+		TargetSHA:                "test-sha",
+	}
+	mock := setupMockPRClient(nil, nil, false, nil, nil)
+	mock.ListChecksFunc = func(ctx context.Context, sha string, options *github.ListCheckRunsOptions) ([]*github.CheckRun, error) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	err := run(context.Background(), cfg, setupEnvironment(t, action), action, mock, newFakeClock())
+
+	var timeoutErr *TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, time.Minute, timeoutErr.Timeout)
+	assert.EqualError(t, err, "required checks timed out after 1m0s")
+}
+
+func TestRun_MissingChecksError(t *testing.T) {
+	action, _ := setupAction("pull-request.opened")
+	cfg := &Config{
+		RequiredWorkflowPatterns: []Pattern{{Pattern: "unit-tests", MinCount: 2, Description: "one per go version"}},
+		PollFrequency:            30 * time.Second,
+		TargetSHA:                "test-sha",
+	}
+	checkRuns := []*github.CheckRun{
+		{
+			Name:       github.String("unit-tests (1.22)"),
+			Status:     github.String(StatusCompleted),
+			Conclusion: github.String(ConclusionSuccess),
+		},
+	}
+
+	err := run(context.Background(), cfg, setupEnvironment(t, action), action, setupMockPRClient(checkRuns, nil, false, nil, nil), newFakeClock())
+
+	var missingErr *MissingChecksError
+	require.ErrorAs(t, err, &missingErr)
+	assert.Equal(t, []MissingPattern{{Pattern: "unit-tests", Description: "one per go version", Found: 1, MinCount: 2}}, missingErr.Missing)
+	assert.EqualError(t, err, `required checks not found: ["unit-tests"]`)
+}
+
+func TestRun_FailedChecksError(t *testing.T) {
+	action, _ := setupAction("pull-request.opened")
+	cfg := &Config{
+		RequiredWorkflowPatterns: NewPatterns("unit-tests"),
+		PollFrequency:            30 * time.Second,
+		TargetSHA:                "test-sha",
+	}
+	checkRuns := []*github.CheckRun{
+		{
+			Name:       github.String("unit-tests"),
+			Status:     github.String(StatusCompleted),
+			Conclusion: github.String(ConclusionCancelled),
+			DetailsURL: github.String("https://example.com/unit-tests"),
+		},
+	}
+
+	err := run(context.Background(), cfg, setupEnvironment(t, action), action, setupMockPRClient(checkRuns, nil, false, nil, nil), newFakeClock())
+
+	var failedErr *FailedChecksError
+	require.ErrorAs(t, err, &failedErr)
+	assert.Equal(t, []FailedCheck{{Name: "unit-tests", Pattern: "unit-tests", Conclusion: ConclusionCancelled, DetailsURL: "https://example.com/unit-tests"}}, failedErr.Failed)
+	assert.EqualError(t, err, `required checks failed: ["unit-tests"]`)
+}
+
+func TestRun_ContextCancelled(t *testing.T) {
+	action, _ := setupAction("pull-request.opened")
+	cfg := &Config{
+		RequiredWorkflowPatterns: NewPatterns("required-check-1"),
+		PollFrequency:            30 * time.Second,
+		TargetSHA:                "test-sha",
+	}
+	checkRuns := []*github.CheckRun{
+		{
+			Name:   github.String("required-check-1"),
+			Status: github.String(StatusInProgress),
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pr := setupMockPRClient(checkRuns, nil, false, nil, nil)
+	listChecks := pr.ListChecksFunc
+	pr.ListChecksFunc = func(ctx context.Context, sha string, options *github.ListCheckRunsOptions) ([]*github.CheckRun, error) {
+		// cancel while the check is still in progress
+		cancel()
+		return listChecks(ctx, sha, options)
+	}
+
+	err := run(ctx, cfg, setupEnvironment(t, action), action, pr, newFakeClock())
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRun_StepSummary(t *testing.T) {
+	cfg := &Config{
+		RequiredWorkflowPatterns: NewPatterns("build", "lint"),
+		ConditionalPathWorkflowPatterns: map[string]PathRule{
+			"**/*.go": {Patterns: NewPatterns("go-tests")},
+			"docs/**": {Patterns: NewPatterns("docs")},
+		},
+		CheckApps:     map[string][]string{"lint": {"github-actions"}},
+		PollFrequency: 30 * time.Second,
+		TargetSHA:     "test-sha",
+	}
+	checkRuns := []*github.CheckRun{
+		{
+			Name:        github.String("build"),
+			Status:      github.String(StatusCompleted),
+			Conclusion:  github.String(ConclusionSuccess),
+			StartedAt:   &github.Timestamp{Time: testStart.Add(-2 * time.Minute)},
+			CompletedAt: &github.Timestamp{Time: testStart.Add(-30 * time.Second)},
+			DetailsURL:  github.String("https://example.com/build"),
+		},
+		{
+			Name:       github.String("go-tests"),
+			Status:     github.String(StatusCompleted),
+			Conclusion: github.String(ConclusionFailure),
+		},
+	}
+	prFiles := []*github.CommitFile{{Filename: github.String("pkg/main.go")}}
+
+	testCases := map[string]struct {
+		checkRuns []*github.CheckRun
+		expected  []string
+	}{
+		"failure": {
+			checkRuns: checkRuns,
+			expected: []string{
+				`:x: required checks not found: ["lint"]`,
+				"| `build` | build | :white_check_mark: completed | success | 1m30s | [details](https://example.com/build) |",
+				"| `go-tests` | go-tests | :x: completed | failure |  |  |",
+				"| `lint` | | :grey_question: not found | | | |",
+				"| `**/*.go` | Matched `pkg/main.go`, requiring `go-tests` |",
+				"| `docs/**` | No changed files matched |",
+			},
+		},
+		"suspicious": {
+			checkRuns: append(checkRuns[:2:2],
+				&github.CheckRun{Name: github.String("lint"), Status: github.String(StatusCompleted), Conclusion: github.String(ConclusionSuccess), App: &github.App{Slug: github.String("spoofer")}},
+			),
+			expected: []string{
+				"## Required checks",
+				"| `lint` | | :grey_question: not found | | | |",
+				"### Suspicious checks",
+				"| lint | `spoofer` | `lint` | `github-actions` |",
+			},
+		},
+		"success": {
+			checkRuns: append(checkRuns[:1:1],
+				&github.CheckRun{Name: github.String("lint"), Status: github.String(StatusCompleted), Conclusion: github.String(ConclusionSuccess), App: &github.App{Slug: github.String("github-actions")}},
+				&github.CheckRun{Name: github.String("go-tests"), Status: github.String(StatusCompleted), Conclusion: github.String(ConclusionSuccess)},
+			),
+			expected: []string{
+				":white_check_mark: All required checks passed",
+				"| `go-tests` | go-tests | :white_check_mark: completed | success |  |  |",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			summaryFile := filepath.Join(t.TempDir(), "summary.md")
+			action, _ := setupActionWithEnv("pull-request.opened", map[string]string{"GITHUB_STEP_SUMMARY": summaryFile})
+
+			_ = run(context.Background(), cfg, setupEnvironment(t, action), action, setupMockPRClient(tc.checkRuns, nil, false, prFiles, nil), newFakeClock())
+
+			summary, err := os.ReadFile(summaryFile)
+			require.NoError(t, err)
+			for _, line := range tc.expected {
+				assert.Contains(t, string(summary), line)
+			}
+			assert.True(t, strings.HasPrefix(string(summary), "## Required checks"), "summary starts with the heading")
+		})
+	}
+}
+
+func TestRun_Outputs(t *testing.T) {
+	cfg := &Config{
+		RequiredWorkflowPatterns:  NewPatterns("build", "lint", "tests"),
+		MissingRequiredRetryCount: 0,
+		PollFrequency:             30 * time.Second,
+		TargetSHA:                 "test-sha",
+	}
+	checkRuns := []*github.CheckRun{
+		{
+			Name:       github.String("build"),
+			Status:     github.String(StatusCompleted),
+			Conclusion: github.String(ConclusionFailure),
+		},
+		{
+			Name:       github.String("lint"),
+			Status:     github.String(StatusCompleted),
+			Conclusion: github.String(ConclusionSuccess),
+		},
+	}
+
+	testCases := map[string]struct {
+		checkRuns []*github.CheckRun
+		expected  map[string]string
+	}{
+		"missing": {
+			checkRuns: checkRuns,
+			expected: map[string]string{
+				outputs.Result:          ResultMissing,
+				outputs.FailedChecks:    `["build"]`,
+				outputs.MissingPatterns: `["tests"]`,
+				outputs.MatchedChecks:   `["build","lint"]`,
+			},
+		},
+		"failed": {
+			checkRuns: append(checkRuns[:2:2], &github.CheckRun{Name: github.String("tests"), Status: github.String(StatusCompleted), Conclusion: github.String(ConclusionSuccess)}),
+			expected: map[string]string{
+				outputs.Result:          ResultFailed,
+				outputs.FailedChecks:    `["build"]`,
+				outputs.MissingPatterns: `[]`,
+				outputs.MatchedChecks:   `["build","lint","tests"]`,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			outputFile := filepath.Join(t.TempDir(), "output")
+			action, _ := setupActionWithEnv("pull-request.opened", map[string]string{"GITHUB_OUTPUT": outputFile})
+
+			err := run(context.Background(), cfg, setupEnvironment(t, action), action, setupMockPRClient(tc.checkRuns, nil, false, nil, nil), newFakeClock())
+			require.Error(t, err)
+
+			got := readOutputs(t, outputFile)
+			for k, v := range tc.expected {
+				assert.Equal(t, v, got[k], k)
+			}
+
+			var e evaluation
+			require.NoError(t, json.Unmarshal([]byte(got[outputs.Evaluation]), &e))
+			assert.Equal(t, tc.expected[outputs.Result], e.Result)
+			assert.Equal(t, err.Error(), e.Error)
+			assert.Equal(t, VerdictFail, e.Checks[0].Verdict)
+		})
+	}
+}
+
+// readOutputs parses the multiline outputs written to the GITHUB_OUTPUT file.
+func readOutputs(t *testing.T, path string) map[string]string {
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	values := map[string]string{}
+	lines := strings.Split(string(b), "\n")
+	for i := 0; i < len(lines); i++ {
+		name, delimiter, ok := strings.Cut(lines[i], "<<")
+		if !ok {
+			continue
+		}
+		var value []string
+		for i++; i < len(lines) && lines[i] != delimiter; i++ {
+			value = append(value, lines[i])
+		}
+		values[name] = strings.Join(value, "\n")
+	}
+	return values
+}
+
+func TestRun_PublishCheckRun(t *testing.T) {
+	testCases := map[string]struct {
+		conclusion         string
+		expectedConclusion string
+		expectedTitle      string
+		assertError        assert.ErrorAssertionFunc
+	}{
+		"success": {
+			conclusion:         ConclusionSuccess,
+			expectedConclusion: ConclusionSuccess,
+			expectedTitle:      "All required checks passed",
+			assertError:        assert.NoError,
+		},
+		"failure": {
+			conclusion:         ConclusionFailure,
+			expectedConclusion: ConclusionFailure,
+			expectedTitle:      "Required checks failed",
+			assertError:        xassert.ErrorContains(`required checks failed: ["required-check-1"]`),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			action, _ := setupAction("pull-request.opened")
+			cfg := &Config{
+				RequiredWorkflowPatterns: NewPatterns("required-check"),
+				PollFrequency:            30 * time.Second,
+				TargetSHA:                "test-sha",
+				CheckRunName:             "required-checks",
+			}
+			checkRuns := []*github.CheckRun{
+				{
+					// the published check run matches the pattern, but is not waited on
+					ID:     github.Int64(99),
+					Name:   github.String("required-checks"),
+					Status: github.String(StatusInProgress),
+				},
+				{
+					ID:         github.Int64(1),
+					Name:       github.String("required-check-1"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(tc.conclusion),
+				},
+			}
+			pr := &mockCheckRunClient{mockPullRequestClient: setupMockPRClient(checkRuns, nil, false, nil, nil)}
+
+			err := run(context.Background(), cfg, setupEnvironment(t, action), action, pr, newFakeClock())
+
+			tc.assertError(t, err)
+			require.Len(t, pr.Created, 1)
+			assert.Equal(t, "required-checks", pr.Created[0].Name)
+			assert.Equal(t, "test-sha", pr.Created[0].HeadSHA)
+			assert.Equal(t, StatusInProgress, pr.Created[0].GetStatus())
+
+			require.Len(t, pr.Updated, 2)
+			assert.Equal(t, "1 of 1 required checks completed", pr.Updated[0].Output.GetTitle())
+			last := pr.Updated[len(pr.Updated)-1]
+			assert.Equal(t, StatusCompleted, last.GetStatus())
+			assert.Equal(t, tc.expectedConclusion, last.GetConclusion())
+			assert.Equal(t, tc.expectedTitle, last.Output.GetTitle())
+			assert.Contains(t, last.Output.GetText(), "| `required-check` | required-check-1 |")
+		})
+	}
+}
+
+func TestRun_TruncatedChangedFiles(t *testing.T) {
+	action, out := setupAction("pull-request.opened")
+	cfg := &Config{
+		RequiredWorkflowPatterns: NewPatterns("required-check-1"),
+		ConditionalPathWorkflowPatterns: map[string]PathRule{
+			"**/*.sql": {Patterns: NewPatterns("validate-migrations")},
+		},
+		PollFrequency: 30 * time.Second,
+		TargetSHA:     "test-sha",
+	}
+	checkRuns := []*github.CheckRun{
+		{
+			Name:       github.String("required-check-1"),
+			Status:     github.String(StatusCompleted),
+			Conclusion: github.String(ConclusionSuccess),
+		},
+	}
+	files := make([]*github.CommitFile, maxPullRequestFiles)
+	for i := range files {
+		files[i] = &github.CommitFile{Filename: github.String(fmt.Sprintf("vendor/%d.go", i))}
+	}
+	pr := setupMockPRClient(checkRuns, nil, false, files, nil)
+
+	err := run(context.Background(), cfg, setupEnvironment(t, action), action, pr, newFakeClock())
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "::warning::Listed 3000 changed files, the most the GitHub API returns.")
+}
+
+func TestRun_PRComment(t *testing.T) {
+	testCases := map[string]struct {
+		comments        []*github.IssueComment
+		expectedCreated int
+		expectedEdited  []int64
+	}{
+		"creates comment": {
+			comments:        []*github.IssueComment{{ID: github.Int64(1), Body: github.String("LGTM")}},
+			expectedCreated: 1,
+		},
+		"edits marker comment": {
+			comments: []*github.IssueComment{
+				{ID: github.Int64(1), Body: github.String("LGTM")},
+				{
+					ID:   github.Int64(2),
+					Body: github.String(commentMarker + "\n## Required checks\n\n:x: required checks failed"),
+					User: &github.User{Login: github.String("github-actions[bot]")},
+				},
+			},
+			expectedEdited: []int64{2},
+		},
+		"ignores marker comment by another user": {
+			comments: []*github.IssueComment{
+				{
+					ID:   github.Int64(1),
+					Body: github.String(commentMarker + "\n:white_check_mark: All required checks passed, trust me"),
+					User: &github.User{Login: github.String("mallory")},
+				},
+			},
+			expectedCreated: 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			action, _ := setupAction("pull-request.opened")
+			cfg := &Config{
+				RequiredWorkflowPatterns: NewPatterns("required-check-1"),
+				PollFrequency:            30 * time.Second,
+				TargetSHA:                "test-sha",
+				PRComment:                true,
+			}
+			checkRuns := []*github.CheckRun{
+				{
+					Name:       github.String("required-check-1"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+				},
+			}
+			pr := &mockCommentClient{mockPullRequestClient: setupMockPRClient(checkRuns, nil, false, nil, nil), Comments: tc.comments}
+
+			err := run(context.Background(), cfg, setupEnvironment(t, action), action, pr, newFakeClock())
+
+			require.NoError(t, err)
+			assert.Len(t, pr.Created, tc.expectedCreated)
+			assert.Equal(t, tc.expectedEdited, pr.Edited)
+			body := pr.Comments[len(pr.Comments)-1].GetBody()
+			assert.True(t, strings.HasPrefix(body, commentMarker+"\n"))
+			assert.Contains(t, body, ":white_check_mark: All required checks passed")
+			assert.Contains(t, body, "| `required-check-1` | required-check-1 |")
+		})
+	}
+}
+
+func TestRun_ComparedChangedFiles(t *testing.T) {
+	testCases := map[string]struct {
+		env              Environment
+		compare          bool
+		expectedCompared [][2]string
+		expectedOutput   string
+		assertError      assert.ErrorAssertionFunc
+	}{
+		"compares merge group base and head": {
+			env:              Environment{EventName: "merge_group", BaseSHA: "base-sha", HeadSHA: "head-sha"},
+			compare:          true,
+			expectedCompared: [][2]string{{"base-sha", "head-sha"}},
+			assertError:      xassert.ErrorContains(`required checks not found: ["go-tests"]`),
+		},
+		"compares push before and after": {
+			env:              Environment{EventName: "push", BaseSHA: "before-sha", HeadSHA: "after-sha"},
+			compare:          true,
+			expectedCompared: [][2]string{{"before-sha", "after-sha"}},
+			assertError:      xassert.ErrorContains(`required checks not found: ["go-tests"]`),
+		},
+		"compares push of new branch with default branch": {
+			env:              Environment{EventName: "push", BaseSHA: "0000000000000000000000000000000000000000", HeadSHA: "after-sha", DefaultBranch: "main"},
+			compare:          true,
+			expectedCompared: [][2]string{{"main", "after-sha"}},
+			assertError:      xassert.ErrorContains(`required checks not found: ["go-tests"]`),
+		},
+		"skips push of new branch without default branch": {
+			env:            Environment{EventName: "push", BaseSHA: "0000000000000000000000000000000000000000", HeadSHA: "after-sha"},
+			compare:        true,
+			expectedOutput: "::warning::Skipping path globs, so their conditional checks are not required: no changed files: no previous commit for push, e.g. a new branch or tag",
+			assertError:    assert.NoError,
+		},
+		"compares base and head without pull request": {
+			env:              Environment{BaseSHA: "base-sha", HeadSHA: "head-sha"},
+			compare:          true,
+			expectedCompared: [][2]string{{"base-sha", "head-sha"}},
+			assertError:      xassert.ErrorContains(`required checks not found: ["go-tests"]`),
+		},
+		"skips without compare client": {
+			env:         Environment{EventName: "merge_group", BaseSHA: "base-sha", HeadSHA: "head-sha"},
+			assertError: assert.NoError,
+		},
+		"skips without base and head": {
+			env:         Environment{EventName: "merge_group"},
+			compare:     true,
+			assertError: assert.NoError,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			action, output := setupAction("pull-request.opened")
+			cfg := &Config{
+				RequiredWorkflowPatterns: NewPatterns("required-check-1"),
+				ConditionalPathWorkflowPatterns: map[string]PathRule{
+					"**/*.go": {Patterns: NewPatterns("go-tests")},
+				},
+				PollFrequency: 30 * time.Second,
+				TargetSHA:     "test-sha",
+			}
+			checkRuns := []*github.CheckRun{
+				{
+					Name:       github.String("required-check-1"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+				},
+			}
+			mock := setupMockPRClient(checkRuns, nil, false, nil, nil)
+			mock.ListFilesFunc = func(ctx context.Context, options *github.ListOptions) ([]*github.CommitFile, error) {
+				t.Fatal("listed pull request files for " + tc.env.EventName)
+				return nil, nil
+			}
+			var pr PRClient = mock
+			compare := &mockCompareClient{mockPullRequestClient: mock, Files: []*github.CommitFile{{Filename: github.String("pkg/main.go")}}}
+			if tc.compare {
+				pr = compare
+			}
+
+			err := run(context.Background(), cfg, tc.env, action, pr, newFakeClock())
+
+			tc.assertError(t, err)
+			assert.Equal(t, tc.expectedCompared, compare.Compared)
+			assert.Contains(t, output.String(), tc.expectedOutput)
+		})
+	}
+}
+
+func TestRun_ErrorAnnotations(t *testing.T) {
+	testCases := map[string]struct {
+		checkRuns     []*github.CheckRun
+		expectedLines []string
+	}{
+		"failed": {
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("unit-tests"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionFailure),
+					DetailsURL: github.String("https://github.com/RoryQ/required-checks/actions/runs/1/job/2"),
+					Output: &github.CheckRunOutput{
+						Title:   github.String("2 tests failed"),
+						Summary: github.String("TestRun failed"),
+					},
+				},
+				{
+					Name:       github.String("lint"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionTimedOut),
+				},
+			},
+			expectedLines: []string{
+				`::error title=unit-tests::Required check "unit-tests" concluded failure%0A2 tests failed%0ATestRun failed%0ADetails: https://github.com/RoryQ/required-checks/actions/runs/1/job/2`,
+				`::error title=lint::Required check "lint" concluded timed_out`,
+			},
+		},
+		"missing": {
+			checkRuns: []*github.CheckRun{
+				{
+					Name:       github.String("unit-tests"),
+					Status:     github.String(StatusCompleted),
+					Conclusion: github.String(ConclusionSuccess),
+				},
+			},
+			expectedLines: []string{
+				`::error title=lint::Required check not found for pattern "lint"`,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			action, output := setupAction("pull-request.opened")
+			cfg := &Config{
+				RequiredWorkflowPatterns: NewPatterns("unit-tests", "lint"),
+				PollFrequency:            30 * time.Second,
+				TargetSHA:                "test-sha",
+			}
+
+			err := run(context.Background(), cfg, setupEnvironment(t, action), ActionReporter{action}, setupMockPRClient(tc.checkRuns, nil, false, nil, nil), newFakeClock())
+
+			require.Error(t, err)
+			for _, line := range tc.expectedLines {
+				assert.Contains(t, output.String(), line)
+			}
+		})
+	}
+}
+
+func setupEnvironment(t *testing.T, action *githubactions.Action) Environment {
+	t.Helper()
+	env, err := EnvironmentFromAction(action)
+	require.NoError(t, err)
+	return env
+}
+
+func TestWait_LogReporter(t *testing.T) {
+	out := new(bytes.Buffer)
+	cfg := &Config{
+		RequiredWorkflowPatterns: NewPatterns("required-check-1"),
+		TargetSHA:                "test-sha",
+	}
+	checkRuns := []*github.CheckRun{
+		{
+			Name:       github.String("required-check-1"),
+			Status:     github.String(StatusCompleted),
+			Conclusion: github.String(ConclusionSuccess),
+		},
+	}
+
+	err := Wait(context.Background(), cfg, Environment{}, LogReporter{W: out}, setupMockPRClient(checkRuns, nil, false, nil, nil))
+
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Got 1 checks\n")
+	assert.Contains(t, out.String(), "All checks completed\n")
+}
+
+func TestRun_InvalidPatternFailsBeforeWaiting(t *testing.T) {
+	action, output := setupAction("pull-request.opened")
+	cfg := &Config{
+		RequiredWorkflowPatterns: NewPatterns("tests("),
+		InitialDelay:             time.Minute,
+		TargetSHA:                "test-sha",
+	}
+
+	err := run(context.Background(), cfg, setupEnvironment(t, action), action, setupMockPRClient(nil, nil, false, nil, nil), newFakeClock())
+
+	assert.ErrorContains(t, err, `invalid pattern "tests("`)
+	assert.NotContains(t, output.String(), "Waiting 1m0s before initial check")
+}
+
+func TestConfig_Ruleset(t *testing.T) {
+	cfg := &Config{
+		RequiredWorkflowPatterns: NewPatterns("tests"),
+		CheckApps:                map[string][]string{"tests": {"github-actions"}},
+		ConclusionPolicy:         ConclusionPolicy{ConclusionSkipped: VerdictFail},
+	}
+
+	rules, err := cfg.Ruleset([]Pattern{{Pattern: "tests", MinCount: 2}, {Pattern: "lint"}})
+
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "tests", rules[0].String())
+	assert.Equal(t, 2, rules[0].MinCount)
+	assert.Equal(t, []string{"github-actions"}, rules[0].Apps)
+	assert.Equal(t, VerdictFail, rules[0].Policy[ConclusionSkipped])
+	assert.Equal(t, "lint", rules[1].String())
+	assert.Empty(t, rules[1].Apps)
+	assert.Equal(t, VerdictFail, rules[1].Policy[ConclusionSkipped])
+	assert.Empty(t, cfg.RequiredWorkflowPatterns[0].MinCount, "the config patterns are not modified")
+
+	_, err = (&Config{RequiredWorkflowPatterns: NewPatterns("(tests")}).Ruleset(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,222 @@
+package reqcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/samber/lo"
+)
+
+const (
+	// maxPullRequestFiles is the most files GitHub lists for a pull request.
+	maxPullRequestFiles = 3000
+	// maxComparedFiles is the most files GitHub lists for a comparison of two commits.
+	maxComparedFiles = 300
+)
+
+// File statuses, as listed by the GitHub API.
+const (
+	FileAdded    = "added"
+	FileModified = "modified"
+	FileRemoved  = "removed"
+	FileRenamed  = "renamed"
+	FileCopied   = "copied"
+	FileChanged  = "changed"
+)
+
+var fileStatuses = []string{FileAdded, FileModified, FileRemoved, FileRenamed, FileCopied, FileChanged}
+
+// ChangedFile is a file changed by the event.
+type ChangedFile struct {
+	Filename string
+	// PreviousFilename is the name of the file before it was renamed or copied.
+	PreviousFilename string
+	// Status is how the file changed, e.g. added or removed, or empty if unknown.
+	Status string
+}
+
+// Names returns the name of the file, and its previous name if it was renamed.
+func (f ChangedFile) Names() []string {
+	if f.Status == FileRenamed && f.PreviousFilename != "" {
+		return []string{f.Filename, f.PreviousFilename}
+	}
+	return []string{f.Filename}
+}
+
+// ParseChangedFile parses a line of git diff --name-status output, e.g. "D\tdocs/old.md" or
+// "R100\told.go\tnew.go". A line without a status is the name of a file with an unknown status.
+func ParseChangedFile(line string) ChangedFile {
+	fields := strings.Split(line, "\t")
+	if len(fields) < 2 {
+		return ChangedFile{Filename: line}
+	}
+	status := nameStatus(fields[0])
+	if len(fields) == 3 {
+		return ChangedFile{Filename: fields[2], PreviousFilename: fields[1], Status: status}
+	}
+	return ChangedFile{Filename: fields[1], Status: status}
+}
+
+// nameStatus returns the file status of the git diff --name-status letter, ignoring the similarity score.
+func nameStatus(s string) string {
+	if s == "" {
+		return ""
+	}
+	switch s[0] {
+	case 'A':
+		return FileAdded
+	case 'D':
+		return FileRemoved
+	case 'R':
+		return FileRenamed
+	case 'C':
+		return FileCopied
+	case 'T':
+		return FileChanged
+	default:
+		return FileModified
+	}
+}
+
+// errNoChangedFiles is returned when the changed files cannot be listed for the event, so path globs are skipped.
+var errNoChangedFiles = errors.New("no changed files")
+
+// CompareClient lists the files changed between two commits. PRClient implementations that are also a CompareClient
+// can list the changed files of events without a pull request, such as merge_group and push.
+type CompareClient interface {
+	CompareFiles(ctx context.Context, base, head string) ([]*github.CommitFile, error)
+}
+
+// ListChangedFiles lists the files changed by the event: the files changed since the merge base in the local checkout
+// if configured, otherwise the pull request files, or the files changed between the base and head commits of a merge
+// group, push, or an environment without a pull request such as the wait command given --base-sha.
+func ListChangedFiles(ctx context.Context, env Environment, cfg *Config, reporter Reporter, pr PRClient) ([]ChangedFile, error) {
+	if cfg.GitChangedFiles {
+		if err := checkCompareRange(env); err != nil {
+			return nil, err
+		}
+		reporter.Infof("Listing changed files %s...%s from the git checkout", env.BaseSHA, env.HeadSHA)
+		return gitChangedFiles(ctx, "", env.BaseSHA, env.HeadSHA)
+	}
+
+	switch {
+	case env.EventName == "merge_group", env.EventName == "push", env.PRNumber == 0 && env.BaseSHA != "":
+		return compareFiles(ctx, env, reporter, pr)
+	}
+	return listPullRequestFiles(ctx, reporter, pr)
+}
+
+// compareFiles lists the files changed between the base and head commits of the environment.
+func compareFiles(ctx context.Context, env Environment, reporter Reporter, pr PRClient) ([]ChangedFile, error) {
+	client, ok := pr.(CompareClient)
+	if !ok {
+		return nil, fmt.Errorf("%w: comparing commits is not supported by the client", errNoChangedFiles)
+	}
+	// A push that creates a branch or tag has no previous commit, so compare against the default branch instead.
+	if env.BaseSHA != "" && isZeroSHA(env.BaseSHA) && env.DefaultBranch != "" {
+		reporter.Infof("No previous commit for %s, comparing against the default branch %s", env.EventName, env.DefaultBranch)
+		env.BaseSHA = env.DefaultBranch
+	}
+	if err := checkCompareRange(env); err != nil {
+		return nil, err
+	}
+
+	reporter.Infof("Comparing changed files %s...%s", env.BaseSHA, env.HeadSHA)
+	files, err := client.CompareFiles(ctx, env.BaseSHA, env.HeadSHA)
+	if err != nil {
+		return nil, err
+	}
+	warnTruncated(reporter, len(files), maxComparedFiles)
+	return changedFiles(files), nil
+}
+
+func listPullRequestFiles(ctx context.Context, reporter Reporter, pr PRClient) ([]ChangedFile, error) {
+	files, err := pr.ListFiles(ctx, nil)
+	if isNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	warnTruncated(reporter, len(files), maxPullRequestFiles)
+	return changedFiles(files), nil
+}
+
+// warnTruncated warns that files beyond the GitHub API limit are not matched against the path globs.
+func warnTruncated(reporter Reporter, n, limit int) {
+	if n < limit {
+		return
+	}
+	reporter.Warningf("Listed %d changed files, the most the GitHub API returns. Files beyond the limit are not matched "+
+		"against the path globs, so their conditional checks may not be required. Set git_changed_files to list the "+
+		"changed files from the git checkout instead.", n)
+}
+
+// checkCompareRange checks the environment has base and head commits to compare.
+func checkCompareRange(env Environment) error {
+	if env.BaseSHA == "" || env.HeadSHA == "" {
+		return fmt.Errorf("%w: no base and head commits for %s", errNoChangedFiles, env.EventName)
+	}
+	// A push that creates a branch or tag has no previous commit to compare against.
+	if isZeroSHA(env.BaseSHA) {
+		return fmt.Errorf("%w: no previous commit for %s, e.g. a new branch or tag", errNoChangedFiles, env.EventName)
+	}
+	return nil
+}
+
+// isZeroSHA reports whether the sha is all zeros, as used by push events for a ref that did not exist.
+func isZeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// gitChangedFiles lists the files changed between the merge base of the base and head commits and the head commit, in
+// the git repository at dir. Both commits must have been fetched, e.g. with fetch-depth: 0.
+func gitChangedFiles(ctx context.Context, dir, base, head string) ([]ChangedFile, error) {
+	mergeBase, err := git(ctx, dir, "merge-base", base, head)
+	if err != nil {
+		return nil, err
+	}
+	out, err := git(ctx, dir, "diff", "--name-status", "--find-renames", "-z", strings.TrimSpace(mergeBase), head)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each file is its status followed by its name, or its previous and new names if it was renamed or copied.
+	var files []ChangedFile
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status := nameStatus(fields[i])
+		file := ChangedFile{Filename: fields[i+1], Status: status}
+		if (status == FileRenamed || status == FileCopied) && i+2 < len(fields) {
+			file.PreviousFilename, file.Filename = fields[i+1], fields[i+2]
+			i++
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// git runs the git command in dir, returning its output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+func changedFiles(files []*github.CommitFile) []ChangedFile {
+	return lo.Map(files, func(item *github.CommitFile, _ int) ChangedFile {
+		return ChangedFile{Filename: item.GetFilename(), PreviousFilename: item.GetPreviousFilename(), Status: item.GetStatus()}
+	})
+}
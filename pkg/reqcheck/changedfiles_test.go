@@ -0,0 +1,61 @@
+package reqcheck
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/roryq/required-checks/pkg/xassert"
+)
+
+func TestGitChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	gitCommit := func(files map[string]string, message string) string {
+		t.Helper()
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		}
+		_, err := git(context.Background(), dir, "add", "-A")
+		require.NoError(t, err)
+		_, err = git(context.Background(), dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", message)
+		require.NoError(t, err)
+		sha, err := git(context.Background(), dir, "rev-parse", "HEAD")
+		require.NoError(t, err)
+		return strings.TrimSpace(sha)
+	}
+	_, err := git(context.Background(), dir, "init", "-q", "-b", "main")
+	require.NoError(t, err)
+
+	migration := strings.Repeat("create table example (id int);\n", 10)
+	gitCommit(map[string]string{"README.md": "readme", "db/001.sql": migration, "docs/old.md": "docs"}, "initial")
+	_, err = git(context.Background(), dir, "checkout", "-q", "-b", "feature")
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(filepath.Join(dir, "db/001.sql"), filepath.Join(dir, "db/002.sql")))
+	require.NoError(t, os.Remove(filepath.Join(dir, "docs/old.md")))
+	head := gitCommit(map[string]string{"README.md": "changed", "pkg/main.go": "package main", "pkg/with space.go": "package main"}, "feature")
+	_, err = git(context.Background(), dir, "checkout", "-q", "main")
+	require.NoError(t, err)
+	base := gitCommit(map[string]string{"docs/index.md": "docs"}, "main")
+
+	files, err := gitChangedFiles(context.Background(), dir, base, head)
+
+	require.NoError(t, err)
+	// docs/index.md changed on the base branch after the merge base, so it is not a changed file.
+	assert.ElementsMatch(t, []ChangedFile{
+		{Filename: "README.md", Status: FileModified},
+		{Filename: "db/002.sql", PreviousFilename: "db/001.sql", Status: FileRenamed},
+		{Filename: "docs/old.md", Status: FileRemoved},
+		{Filename: "pkg/main.go", Status: FileAdded},
+		{Filename: "pkg/with space.go", Status: FileAdded},
+	}, files)
+
+	_, err = gitChangedFiles(context.Background(), dir, "0123456789abcdef0123456789abcdef01234567", head)
+	xassert.ErrorContains("git merge-base")(t, err)
+}
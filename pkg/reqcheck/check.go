@@ -0,0 +1,68 @@
+package reqcheck
+
+import (
+	"github.com/google/go-github/v61/github"
+)
+
+// Sources of a Check. A pattern may be scoped to a single source with a prefix e.g. "status:ci/jenkins".
+const (
+	SourceCheckRun = "check"
+	SourceStatus   = "status"
+)
+
+// State: error, failure, pending, success
+const (
+	StateError   = "error"
+	StateFailure = "failure"
+	StatePending = "pending"
+	StateSuccess = "success"
+)
+
+// Check is a check run or a commit status normalised into the check run model.
+type Check struct {
+	*github.CheckRun
+	Source string
+}
+
+func checkRunsToChecks(runs []*github.CheckRun) []Check {
+	checks := make([]Check, 0, len(runs))
+	for _, r := range runs {
+		checks = append(checks, Check{CheckRun: r, Source: SourceCheckRun})
+	}
+	return checks
+}
+
+func statusesToChecks(statuses []*github.RepoStatus) []Check {
+	checks := make([]Check, 0, len(statuses))
+	for _, s := range statuses {
+		checks = append(checks, statusToCheck(s))
+	}
+	return checks
+}
+
+// statusToCheck maps a commit status onto a check run, so that the status state is represented by the
+// equivalent status and conclusion.
+func statusToCheck(s *github.RepoStatus) Check {
+	run := &github.CheckRun{
+		ID:         s.ID,
+		Name:       s.Context,
+		DetailsURL: s.TargetURL,
+		StartedAt:  s.CreatedAt,
+		Output:     &github.CheckRunOutput{Title: s.Description},
+	}
+
+	switch s.GetState() {
+	case StateSuccess:
+		run.Status = github.String(StatusCompleted)
+		run.Conclusion = github.String(ConclusionSuccess)
+		run.CompletedAt = s.UpdatedAt
+	case StateFailure, StateError:
+		run.Status = github.String(StatusCompleted)
+		run.Conclusion = github.String(ConclusionFailure)
+		run.CompletedAt = s.UpdatedAt
+	default:
+		run.Status = github.String(StatusPending)
+	}
+
+	return Check{CheckRun: run, Source: SourceStatus}
+}
@@ -0,0 +1,37 @@
+package reqcheck
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusToCheck(t *testing.T) {
+	testCases := map[string]struct {
+		state              string
+		expectedStatus     string
+		expectedConclusion string
+	}{
+		"success": {state: StateSuccess, expectedStatus: StatusCompleted, expectedConclusion: ConclusionSuccess},
+		"failure": {state: StateFailure, expectedStatus: StatusCompleted, expectedConclusion: ConclusionFailure},
+		"error":   {state: StateError, expectedStatus: StatusCompleted, expectedConclusion: ConclusionFailure},
+		"pending": {state: StatePending, expectedStatus: StatusPending, expectedConclusion: ""},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			check := statusToCheck(&github.RepoStatus{
+				Context:   github.String("ci/jenkins"),
+				State:     github.String(tc.state),
+				TargetURL: github.String("https://jenkins.example.com/job/1"),
+			})
+
+			assert.Equal(t, SourceStatus, check.Source)
+			assert.Equal(t, "ci/jenkins", check.GetName())
+			assert.Equal(t, "https://jenkins.example.com/job/1", check.GetDetailsURL())
+			assert.Equal(t, tc.expectedStatus, check.GetStatus())
+			assert.Equal(t, tc.expectedConclusion, check.GetConclusion())
+		})
+	}
+}
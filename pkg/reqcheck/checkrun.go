@@ -0,0 +1,127 @@
+package reqcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// maxCheckRunText is the maximum length of a check run output summary or text.
+const maxCheckRunText = 65535
+
+// CheckRunClient creates and updates check runs. PRClient implementations that are also a CheckRunClient can
+// publish the result as a check run.
+type CheckRunClient interface {
+	CreateCheckRun(ctx context.Context, opts github.CreateCheckRunOptions) (*github.CheckRun, error)
+	UpdateCheckRun(ctx context.Context, id int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, error)
+}
+
+// checkRunPublisher publishes the progress and result of waiting as a check run. A nil publisher does nothing.
+type checkRunPublisher struct {
+	client   CheckRunClient
+	reporter Reporter
+	id       int64
+	name     string
+}
+
+// startCheckRun creates the in progress check run against the target sha, if a check run name is configured.
+func startCheckRun(ctx context.Context, cfg *Config, reporter Reporter, pr PRClient, clock Clock) (*checkRunPublisher, error) {
+	if cfg.CheckRunName == "" {
+		return nil, nil
+	}
+	client, ok := pr.(CheckRunClient)
+	if !ok {
+		return nil, errors.New("publishing a check run is not supported by the client")
+	}
+
+	run, err := client.CreateCheckRun(ctx, github.CreateCheckRunOptions{
+		Name:      cfg.CheckRunName,
+		HeadSHA:   cfg.TargetSHA,
+		Status:    github.String(StatusInProgress),
+		StartedAt: &github.Timestamp{Time: clock.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   github.String("Waiting for required checks"),
+			Summary: github.String("Waiting for required checks"),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create check run %q: %w", cfg.CheckRunName, err)
+	}
+	reporter.Infof("Publishing result to check run %q: %s", cfg.CheckRunName, run.GetHTMLURL())
+	return &checkRunPublisher{client: client, reporter: reporter, id: run.GetID(), name: cfg.CheckRunName}, nil
+}
+
+// isSelf reports whether the check is the published check run, so that it is not waited on.
+func (p *checkRunPublisher) isSelf(c Check) bool {
+	return p != nil && c.Source == SourceCheckRun && c.GetID() == p.id
+}
+
+// progress updates the check run with the checks that are still waiting.
+func (p *checkRunPublisher) progress(ctx context.Context, report *summary) {
+	if p == nil {
+		return
+	}
+	d := report.decision
+	completed := len(d.Matches) - len(d.Waiting)
+	title := fmt.Sprintf("%d of %d required checks completed", completed, len(d.Matches)+len(d.Missing))
+	p.update(ctx, github.UpdateCheckRunOptions{
+		Name:   p.name,
+		Status: github.String(StatusInProgress),
+		Output: &github.CheckRunOutput{
+			Title:   github.String(title),
+			Summary: github.String(title),
+			Text:    github.String(truncate(report.tables(), maxCheckRunText)),
+		},
+	})
+}
+
+// complete completes the check run with success, or failure with the error.
+func (p *checkRunPublisher) complete(ctx context.Context, report *summary, err error, clock Clock) {
+	if p == nil {
+		return
+	}
+	conclusion, title := ConclusionSuccess, "All required checks passed"
+	switch {
+	case errors.Is(err, context.Canceled):
+		conclusion, title = ConclusionCancelled, "Cancelled waiting for required checks"
+	case err != nil:
+		conclusion, title = ConclusionFailure, "Required checks failed"
+	}
+	summary := title
+	if err != nil {
+		summary = err.Error()
+	}
+	p.update(ctx, github.UpdateCheckRunOptions{
+		Name:        p.name,
+		Status:      github.String(StatusCompleted),
+		Conclusion:  github.String(conclusion),
+		CompletedAt: &github.Timestamp{Time: clock.Now()},
+		Output: &github.CheckRunOutput{
+			Title:   github.String(title),
+			Summary: github.String(truncate(summary, maxCheckRunText)),
+			Text:    github.String(truncate(report.tables(), maxCheckRunText)),
+		},
+	})
+}
+
+// update updates the check run, warning rather than failing if it cannot be updated.
+func (p *checkRunPublisher) update(ctx context.Context, opts github.UpdateCheckRunOptions) {
+	if _, err := p.client.UpdateCheckRun(ctx, p.id, opts); err != nil {
+		p.reporter.Warningf("Failed to update check run %q: %s", p.name, err)
+	}
+}
+
+// truncate cuts s to at most n bytes, ending with an ellipsis, without splitting a UTF-8 encoded rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	end := n - 3
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "..."
+}
@@ -0,0 +1,43 @@
+package reqcheck
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	testCases := map[string]struct {
+		s        string
+		n        int
+		expected string
+	}{
+		"short": {
+			s:        "tests",
+			n:        10,
+			expected: "tests",
+		},
+		"ascii": {
+			s:        "required checks",
+			n:        10,
+			expected: "require...",
+		},
+		"does not split rune": {
+			// ⌛ is 3 bytes, so cutting at 7 bytes would split it.
+			s:        "tests ⌛ waiting",
+			n:        10,
+			expected: "tests ...",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual := truncate(tc.s, tc.n)
+
+			assert.Equal(t, tc.expected, actual)
+			assert.True(t, utf8.ValidString(actual))
+			assert.LessOrEqual(t, len(actual), tc.n)
+		})
+	}
+}
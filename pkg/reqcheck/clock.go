@@ -0,0 +1,34 @@
+package reqcheck
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and waits for durations to elapse, so that polling can be driven by a fake clock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+// RealClock is the Clock backed by the time package.
+var RealClock Clock = realClock{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// sleep waits for the duration to elapse on the clock, returning the context error if it is cancelled first.
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
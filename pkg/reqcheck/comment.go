@@ -0,0 +1,65 @@
+package reqcheck
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/samber/lo"
+)
+
+// commentMarker tags the pull request comment, so that it is edited on later runs instead of adding a new comment.
+const commentMarker = "<!-- required-checks -->"
+
+// CommentClient lists, creates and edits the comments on the pull request. PRClient implementations that are also a
+// CommentClient can write the summary as a pull request comment. CommentAuthor is the login of the user that the
+// client comments as.
+type CommentClient interface {
+	CommentAuthor(ctx context.Context) (string, error)
+	ListComments(ctx context.Context) ([]*github.IssueComment, error)
+	CreateComment(ctx context.Context, body string) error
+	EditComment(ctx context.Context, id int64, body string) error
+}
+
+// writeComment creates or edits the marker tagged pull request comment with the summary, warning rather than failing
+// if it cannot be written.
+func writeComment(ctx context.Context, cfg *Config, env Environment, reporter Reporter, pr PRClient, report *summary, result error) {
+	if !cfg.PRComment {
+		return
+	}
+	if env.PRNumber == 0 {
+		reporter.Debugf("Skipping pull request comment for event %q", env.EventName)
+		return
+	}
+	if err := upsertComment(ctx, pr, commentMarker+"\n"+report.markdown(result)); err != nil {
+		reporter.Warningf("Failed to write pull request comment: %s", err)
+	}
+}
+
+func upsertComment(ctx context.Context, pr PRClient, body string) error {
+	client, ok := pr.(CommentClient)
+	if !ok {
+		return errors.New("pull request comments are not supported by the client")
+	}
+
+	author, err := client.CommentAuthor(ctx)
+	if err != nil {
+		return err
+	}
+	comments, err := client.ListComments(ctx)
+	if err != nil {
+		return err
+	}
+	// Anyone can write a comment with the marker, so only edit the comments written by the client's user.
+	existing, found := lo.Find(comments, func(item *github.IssueComment) bool {
+		return item.GetUser().GetLogin() == author && strings.HasPrefix(item.GetBody(), commentMarker)
+	})
+	if !found {
+		return client.CreateComment(ctx, body)
+	}
+	if existing.GetBody() == body {
+		return nil
+	}
+	return client.EditComment(ctx, existing.GetID(), body)
+}
@@ -1,10 +1,11 @@
 package reqcheck
 
 import (
+	"context"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/bmatcuk/doublestar/v4"
 	"github.com/niemeyer/pretty"
 	"github.com/sethvargo/go-githubactions"
 	"gopkg.in/yaml.v3"
@@ -13,12 +14,21 @@ import (
 )
 
 type Config struct {
-	RequiredWorkflowPatterns        []string
-	ConditionalPathWorkflowPatterns map[string][]string
+	RequiredWorkflowPatterns        []Pattern
+	ConditionalPathWorkflowPatterns map[string]PathRule
+	CheckApps                       map[string][]string
+	ConclusionPolicy                ConclusionPolicy
 	InitialDelay                    time.Duration
 	PollFrequency                   time.Duration
 	MissingRequiredRetryCount       int
+	Timeout                         time.Duration
 	TargetSHA                       string
+	// CheckRunName is the name of the check run to publish the result to, or empty to not publish one.
+	CheckRunName string
+	// PRComment creates or updates a pull request comment with the summary.
+	PRComment bool
+	// GitChangedFiles lists the changed files from the local git checkout instead of the GitHub API.
+	GitChangedFiles bool
 }
 
 const (
@@ -27,66 +37,199 @@ const (
 	MissingRequiredRetryCountDefault = 2
 )
 
-func ConfigFromInputs(action *githubactions.Action) (*Config, error) {
-	action.Infof("Reading Config From Inputs")
-	c := Config{
+// DefaultConfig returns the config used when no inputs are set.
+func DefaultConfig() *Config {
+	return &Config{
 		InitialDelay:                    InitialDelayDefault,
 		PollFrequency:                   PollFrequencyDefault,
-		ConditionalPathWorkflowPatterns: map[string][]string{},
+		ConditionalPathWorkflowPatterns: map[string]PathRule{},
+		CheckApps:                       map[string][]string{},
 		MissingRequiredRetryCount:       MissingRequiredRetryCountDefault,
 	}
-	requiredWorkflowPatterns := action.GetInput(inputs.RequiredWorkflowPatterns)
-	if requiredWorkflowPatterns != "" {
-		if err := yaml.Unmarshal([]byte(requiredWorkflowPatterns), &c.RequiredWorkflowPatterns); err != nil {
+}
+
+// configInputs are the inputs that override values in the config file.
+var configInputs = []string{
+	inputs.RequiredWorkflowPatterns,
+	inputs.ConditionalPathWorkflowPatterns,
+	inputs.CheckApps,
+	inputs.ConclusionPolicy,
+	inputs.InitialDelaySeconds,
+	inputs.PollFrequencySeconds,
+	inputs.MissingRequiredRetryCount,
+	inputs.Timeout,
+	inputs.CheckRunName,
+	inputs.PRComment,
+	inputs.GitChangedFiles,
+}
+
+// ConfigFromInputs reads the config file, if there is one, and overrides it with the action inputs. The contents
+// client is used to fetch the config file when it is not in the local checkout, and may be nil.
+func ConfigFromInputs(ctx context.Context, action *githubactions.Action, contents ContentsClient) (*Config, error) {
+	action.Infof("Reading Config From Inputs")
+	c := DefaultConfig()
+
+	env, err := EnvironmentFromAction(action)
+	if err != nil {
+		return nil, err
+	}
+
+	file, source, err := loadConfigFile(ctx, action, env, contents)
+	if err != nil {
+		return nil, err
+	}
+	getInput := action.GetInput
+	if file != nil {
+		if err := file.Apply(c); err != nil {
 			return nil, err
 		}
+		if action.GetInput(inputs.ConfigSource) == ConfigSourceBase && canReadBaseConfig(env, contents) {
+			if getInput, err = baseConfigGetInput(action, source); err != nil {
+				return nil, err
+			}
+		}
+		action.Infof("Config precedence: inputs > %s > defaults", source)
+		for _, name := range configInputs {
+			if getInput(name) != "" {
+				action.Infof("Input %s overrides %s", strings.ToLower(name), source)
+			}
+		}
 	}
 
-	pathPatterns := action.GetInput(inputs.ConditionalPathWorkflowPatterns)
-	if pathPatterns != "" {
-		if err := yaml.Unmarshal([]byte(pathPatterns), &c.ConditionalPathWorkflowPatterns); err != nil {
+	if err := ApplyInputs(getInput, action, c); err != nil {
+		return nil, err
+	}
+
+	if file != nil && file.Extends != "" {
+		inherited, err := LoadExtends(ctx, action, contents, file.Extends)
+		if err != nil {
 			return nil, err
 		}
-		for key, _ := range c.ConditionalPathWorkflowPatterns {
-			if !doublestar.ValidatePathPattern(key) {
-				action.Warningf("Invalid workflow pattern: %s", key)
-			}
+		MergeInherited(c, inherited, action)
+		action.Infof("Effective config:\n%s", ConfigFileFrom(c))
+	}
+
+	c.TargetSHA, err = defaultTargetSHA(action)
+	if err != nil {
+		return nil, err
+	}
+
+	action.Infof("Config: %s", pretty.Sprint(*c))
+
+	return c, nil
+}
+
+// ApplyInputs overrides the config with the inputs that are set, where getInput returns the value of an input name
+// from the inputs package.
+func ApplyInputs(getInput func(name string) string, reporter Reporter, c *Config) error {
+	requiredWorkflowPatterns := getInput(inputs.RequiredWorkflowPatterns)
+	if requiredWorkflowPatterns != "" {
+		if err := validateInput(inputs.RequiredWorkflowPatterns, requiredWorkflowPatterns, func(v *validator, node *yaml.Node) {
+			v.patterns(node, "required_workflow_patterns")
+		}); err != nil {
+			return err
+		}
+		var patterns []Pattern
+		if err := yaml.Unmarshal([]byte(requiredWorkflowPatterns), &patterns); err != nil {
+			return err
+		}
+		c.RequiredWorkflowPatterns = patterns
+	}
+
+	pathPatterns := getInput(inputs.ConditionalPathWorkflowPatterns)
+	if pathPatterns != "" {
+		if err := validateInput(inputs.ConditionalPathWorkflowPatterns, pathPatterns, (*validator).pathPatterns); err != nil {
+			return err
+		}
+		var patterns map[string]PathRule
+		if err := yaml.Unmarshal([]byte(pathPatterns), &patterns); err != nil {
+			return err
 		}
+		c.ConditionalPathWorkflowPatterns = patterns
 	}
 
-	if initialDelaySeconds := action.GetInput(inputs.InitialDelaySeconds); initialDelaySeconds != "" {
+	checkApps := getInput(inputs.CheckApps)
+	if checkApps != "" {
+		if err := validateInput(inputs.CheckApps, checkApps, (*validator).checkApps); err != nil {
+			return err
+		}
+		var apps map[string][]string
+		if err := yaml.Unmarshal([]byte(checkApps), &apps); err != nil {
+			return err
+		}
+		c.CheckApps = apps
+	}
+
+	conclusionPolicy := getInput(inputs.ConclusionPolicy)
+	if conclusionPolicy != "" {
+		var policy ConclusionPolicy
+		if err := yaml.Unmarshal([]byte(conclusionPolicy), &policy); err != nil {
+			return err
+		}
+		c.ConclusionPolicy = policy
+	}
+
+	if initialDelaySeconds := getInput(inputs.InitialDelaySeconds); initialDelaySeconds != "" {
 		if ids, err := strconv.Atoi(initialDelaySeconds); err != nil {
-			action.Warningf("Failed to parse InitialDelaySeconds: %s", err)
+			reporter.Warningf("Failed to parse InitialDelaySeconds: %s", err)
 		} else {
 			c.InitialDelay = time.Duration(ids) * time.Second
 		}
 	}
 
-	if pollFrequencySeconds := action.GetInput(inputs.PollFrequencySeconds); pollFrequencySeconds != "" {
+	if pollFrequencySeconds := getInput(inputs.PollFrequencySeconds); pollFrequencySeconds != "" {
 		if pfs, err := strconv.Atoi(pollFrequencySeconds); err != nil {
-			action.Warningf("Failed to parse PollFrequencySeconds: %s", err)
+			reporter.Warningf("Failed to parse PollFrequencySeconds: %s", err)
 		} else {
 			c.PollFrequency = time.Duration(pfs) * time.Second
 		}
 	}
 
-	if missingRequiredRetryCount := action.GetInput(inputs.MissingRequiredRetryCount); missingRequiredRetryCount != "" {
+	if missingRequiredRetryCount := getInput(inputs.MissingRequiredRetryCount); missingRequiredRetryCount != "" {
 		if mrrc, err := strconv.Atoi(missingRequiredRetryCount); err != nil {
-			action.Warningf("Failed to parse MissingRequiredRetryCount: %s", err)
+			reporter.Warningf("Failed to parse MissingRequiredRetryCount: %s", err)
 		} else {
 			c.MissingRequiredRetryCount = mrrc
 		}
 	}
 
-	var err error
-	c.TargetSHA, err = defaultTargetSHA(action)
-	if err != nil {
-		return nil, err
+	if timeout := getInput(inputs.Timeout); timeout != "" {
+		if t, err := parseDuration(timeout); err != nil {
+			reporter.Warningf("Failed to parse Timeout: %s", err)
+		} else {
+			c.Timeout = t
+		}
+	}
+
+	if checkRunName := getInput(inputs.CheckRunName); checkRunName != "" {
+		c.CheckRunName = checkRunName
+	}
+
+	if prComment := getInput(inputs.PRComment); prComment != "" {
+		if b, err := strconv.ParseBool(prComment); err != nil {
+			reporter.Warningf("Failed to parse PRComment: %s", err)
+		} else {
+			c.PRComment = b
+		}
+	}
+
+	if gitChangedFiles := getInput(inputs.GitChangedFiles); gitChangedFiles != "" {
+		if b, err := strconv.ParseBool(gitChangedFiles); err != nil {
+			reporter.Warningf("Failed to parse GitChangedFiles: %s", err)
+		} else {
+			c.GitChangedFiles = b
+		}
 	}
 
-	action.Infof("Config: %s", pretty.Sprint(c))
+	return nil
+}
 
-	return &c, nil
+// parseDuration parses a duration such as 1h30m, or a number of seconds.
+func parseDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(s)
 }
 
 // equivalent of ${{ github.event.pull_request.head.sha || github.sha }}
@@ -1,23 +1,31 @@
 package reqcheck
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-github/v61/github"
 	"github.com/sethvargo/go-githubactions"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/roryq/required-checks/pkg/reqcheck/inputs"
+	"github.com/roryq/required-checks/pkg/xassert"
 )
 
 func TestConfigFromInputs_DefaultValues(t *testing.T) {
 	action, _ := setupAction("pull-request.opened")
 
 	// Test
-	config, err := ConfigFromInputs(action)
+	config, err := ConfigFromInputs(context.Background(), action, nil)
 
 	// Assert
 	require.NoError(t, err)
@@ -39,9 +47,49 @@ func TestConfigFromInputs(t *testing.T) {
 			Input:        inputs.RequiredWorkflowPatterns,
 			Value:        "- pattern1\n- pattern2",
 			SelectConfig: func(config Config) any { return config.RequiredWorkflowPatterns },
-			Expected:     []string{"pattern1", "pattern2"},
+			Expected:     NewPatterns("pattern1", "pattern2"),
 			AssertError:  assert.NoError,
 		},
+		"RuleRequiredWorkflowPattern": {
+			Input: inputs.RequiredWorkflowPatterns,
+			Value: `- plain
+- pattern: unit-tests
+  allowed_conclusions: [success]
+  optional: true
+  timeout: 30m
+  min_count: 3
+  app: github-actions
+  description: matrix unit tests`,
+			SelectConfig: func(config Config) any { return config.RequiredWorkflowPatterns },
+			Expected: []Pattern{
+				{Pattern: "plain"},
+				{
+					Pattern:            "unit-tests",
+					AllowedConclusions: []string{ConclusionSuccess},
+					Optional:           true,
+					Timeout:            30 * time.Minute,
+					MinCount:           3,
+					App:                StringList{"github-actions"},
+					Description:        "matrix unit tests",
+				},
+			},
+			AssertError: assert.NoError,
+		},
+		"RuleWithoutPattern": {
+			Input:       inputs.RequiredWorkflowPatterns,
+			Value:       "- optional: true",
+			AssertError: xassert.ErrorContains("pattern is required"),
+		},
+		"InvalidRegexRequiredWorkflowPattern": {
+			Input:       inputs.RequiredWorkflowPatterns,
+			Value:       "- unit-tests\n- lint(",
+			AssertError: xassert.ErrorContains(`input required_workflow_patterns: line 2, column 3: invalid pattern "lint("`),
+		},
+		"DuplicateRequiredWorkflowPattern": {
+			Input:       inputs.RequiredWorkflowPatterns,
+			Value:       "- unit-tests\n- pattern: unit-tests\n  optional: true",
+			AssertError: xassert.ErrorContains(`line 2, column 12: duplicate pattern "unit-tests", first defined at line 1`),
+		},
 		"ValidConditionalPathWorkflowPatterns": {
 			Input: inputs.ConditionalPathWorkflowPatterns,
 			Value: `path/to/file*:
@@ -50,7 +98,16 @@ func TestConfigFromInputs(t *testing.T) {
 another/path/**:
   - workflow3`,
 			SelectConfig: func(config Config) any { return config.ConditionalPathWorkflowPatterns },
-			Expected:     map[string][]string{"path/to/file*": {"workflow1", "workflow2"}, "another/path/**": {"workflow3"}},
+			Expected:     map[string]PathRule{"path/to/file*": {Patterns: NewPatterns("workflow1", "workflow2")}, "another/path/**": {Patterns: NewPatterns("workflow3")}},
+			AssertError:  assert.NoError,
+		},
+		"PathRuleConditionalPathWorkflowPatterns": {
+			Input: inputs.ConditionalPathWorkflowPatterns,
+			Value: `go:
+  paths: ["**/*.go", "!**/mocks/**"]
+  patterns: [go-unit-tests]`,
+			SelectConfig: func(config Config) any { return config.ConditionalPathWorkflowPatterns },
+			Expected:     map[string]PathRule{"go": {Paths: []string{"**/*.go", "!**/mocks/**"}, Patterns: NewPatterns("go-unit-tests")}},
 			AssertError:  assert.NoError,
 		},
 		"InvalidGlobConditionalPathWorkflowPatterns": {
@@ -58,6 +115,40 @@ another/path/**:
 			Value:       "[^abc:\n  - workflow1\n  - workflow2",
 			AssertError: assert.Error,
 		},
+		"InvalidPathGlobConditionalPathWorkflowPatterns": {
+			Input:       inputs.ConditionalPathWorkflowPatterns,
+			Value:       "src/[a-: [workflow1]",
+			AssertError: xassert.ErrorContains(`input conditional_path_workflow_patterns: line 1, column 1: invalid path glob "src/[a-"`),
+		},
+		"EmptyConditionalPathWorkflowPatterns": {
+			Input:       inputs.ConditionalPathWorkflowPatterns,
+			Value:       "docs/**: []",
+			AssertError: xassert.ErrorContains(`line 1, column 10: patterns for path glob "docs/**" is empty`),
+		},
+		"ValidCheckApps": {
+			Input:        inputs.CheckApps,
+			Value:        "unit-tests: [github-actions, \"15368\"]",
+			SelectConfig: func(config Config) any { return config.CheckApps },
+			Expected:     map[string][]string{"unit-tests": {"github-actions", "15368"}},
+			AssertError:  assert.NoError,
+		},
+		"ValidConclusionPolicy": {
+			Input:        inputs.ConclusionPolicy,
+			Value:        "action_required: fail\nstale: wait\nskipped: pass",
+			SelectConfig: func(config Config) any { return config.ConclusionPolicy },
+			Expected:     ConclusionPolicy{ConclusionActionRequired: VerdictFail, ConclusionStale: VerdictWait, ConclusionSkipped: VerdictPass},
+			AssertError:  assert.NoError,
+		},
+		"InvalidConclusionPolicyVerdict": {
+			Input:       inputs.ConclusionPolicy,
+			Value:       "stale: ignore",
+			AssertError: xassert.ErrorContains(`invalid verdict "ignore"`),
+		},
+		"InvalidConclusionPolicyConclusion": {
+			Input:       inputs.ConclusionPolicy,
+			Value:       "approved: pass",
+			AssertError: xassert.ErrorContains(`invalid conclusion "approved"`),
+		},
 		"ValidInitialDelaySeconds": {
 			Input:        inputs.InitialDelaySeconds,
 			Value:        "30",
@@ -100,6 +191,27 @@ another/path/**:
 			Expected:     MissingRequiredRetryCountDefault,
 			AssertError:  assert.NoError, // Invalid numbers should not cause errors, just warnings
 		},
+		"ValidTimeoutDuration": {
+			Input:        inputs.Timeout,
+			Value:        "30m",
+			SelectConfig: func(config Config) any { return config.Timeout },
+			Expected:     30 * time.Minute,
+			AssertError:  assert.NoError,
+		},
+		"ValidTimeoutSeconds": {
+			Input:        inputs.Timeout,
+			Value:        "90",
+			SelectConfig: func(config Config) any { return config.Timeout },
+			Expected:     90 * time.Second,
+			AssertError:  assert.NoError,
+		},
+		"InvalidTimeout": {
+			Input:        inputs.Timeout,
+			Value:        "not-a-duration",
+			SelectConfig: func(config Config) any { return config.Timeout },
+			Expected:     time.Duration(0),
+			AssertError:  assert.NoError, // Invalid durations should not cause errors, just warnings
+		},
 		"ValidTargetSHA": {
 			Input:        inputs.TargetSHA,
 			Value:        "custom-sha",
@@ -113,7 +225,7 @@ another/path/**:
 		t.Run(name, func(t *testing.T) {
 			action, _ := setupAction("pull-request.opened", tt.Input, tt.Value)
 
-			config, err := ConfigFromInputs(action)
+			config, err := ConfigFromInputs(context.Background(), action, nil)
 			tt.AssertError(t, err)
 			if err != nil {
 				return
@@ -127,13 +239,362 @@ another/path/**:
 func TestConfigFromInputs_InvalidYAML(t *testing.T) {
 	action, _ := setupAction("pull-request.opened", inputs.RequiredWorkflowPatterns, "invalid: yaml: [")
 
-	config, err := ConfigFromInputs(action)
+	config, err := ConfigFromInputs(context.Background(), action, nil)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "yaml")
 	assert.Nil(t, config)
 }
 
+func TestConfigFile_Apply(t *testing.T) {
+	file, err := ParseConfigFile([]byte(`
+required_workflow_patterns:
+  - tests
+  - pattern: lint
+    optional: true
+conditional_path_workflow_patterns:
+  "**/*.go": [go-unit-tests]
+check_apps:
+  tests: [github-actions]
+conclusion_policy:
+  stale: wait
+poll_frequency_seconds: 10
+missing_required_retry_count: 0
+timeout: 15m
+`))
+	require.NoError(t, err)
+
+	config := DefaultConfig()
+	require.NoError(t, file.Apply(config))
+
+	assert.Equal(t, []Pattern{{Pattern: "tests"}, {Pattern: "lint", Optional: true}}, config.RequiredWorkflowPatterns)
+	assert.Equal(t, map[string]PathRule{"**/*.go": {Patterns: NewPatterns("go-unit-tests")}}, config.ConditionalPathWorkflowPatterns)
+	assert.Equal(t, map[string][]string{"tests": {"github-actions"}}, config.CheckApps)
+	assert.Equal(t, ConclusionPolicy{ConclusionStale: VerdictWait}, config.ConclusionPolicy)
+	assert.Equal(t, InitialDelayDefault, config.InitialDelay)
+	assert.Equal(t, 10*time.Second, config.PollFrequency)
+	assert.Equal(t, 0, config.MissingRequiredRetryCount)
+	assert.Equal(t, 15*time.Minute, config.Timeout)
+}
+
+func TestApplyInputs_OverridesConfigFile(t *testing.T) {
+	action, _ := setupAction("pull-request.opened",
+		inputs.RequiredWorkflowPatterns, "- from-input",
+		inputs.PollFrequencySeconds, "5",
+	)
+	config := DefaultConfig()
+	config.RequiredWorkflowPatterns = NewPatterns("from-file")
+	config.PollFrequency = time.Minute
+	config.MissingRequiredRetryCount = 7
+
+	require.NoError(t, ApplyInputs(action.GetInput, action, config))
+
+	assert.Equal(t, NewPatterns("from-input"), config.RequiredWorkflowPatterns)
+	assert.Equal(t, 5*time.Second, config.PollFrequency)
+	assert.Equal(t, 7, config.MissingRequiredRetryCount)
+}
+
+func TestConfigFromInputs_ConfigFile(t *testing.T) {
+	workspace := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(workspace, ".github"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, DefaultConfigPath), []byte(`
+required_workflow_patterns: [from-local-file]
+poll_frequency_seconds: 10
+missing_required_retry_count: 5
+pr_comment: true
+`), 0o644))
+
+	tests := map[string]struct {
+		Env         map[string]string
+		Inputs      []string
+		Contents    *mockContentsClient
+		Expected    *Config
+		Output      []string
+		AssertError assert.ErrorAssertionFunc
+	}{
+		"LocalCheckout": {
+			Env: map[string]string{"GITHUB_WORKSPACE": workspace},
+			Expected: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("from-local-file"),
+				PollFrequency:             10 * time.Second,
+				MissingRequiredRetryCount: 5,
+				PRComment:                 true,
+			},
+			Output:      []string{"Reading config file from local checkout: .github/required-checks.yaml"},
+			AssertError: assert.NoError,
+		},
+		"InputsOverrideFile": {
+			Env:    map[string]string{"GITHUB_WORKSPACE": workspace},
+			Inputs: []string{inputs.RequiredWorkflowPatterns, "- from-input", inputs.ConfigPath, DefaultConfigPath},
+			Expected: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("from-input"),
+				PollFrequency:             10 * time.Second,
+				MissingRequiredRetryCount: 5,
+				PRComment:                 true,
+			},
+			Output: []string{
+				"Config precedence: inputs > config file .github/required-checks.yaml > defaults",
+				"Input required_workflow_patterns overrides config file .github/required-checks.yaml",
+			},
+			AssertError: assert.NoError,
+		},
+		"PRCommentInputOverridesFile": {
+			Env:    map[string]string{"GITHUB_WORKSPACE": workspace},
+			Inputs: []string{inputs.PRComment, "false"},
+			Expected: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("from-local-file"),
+				PollFrequency:             10 * time.Second,
+				MissingRequiredRetryCount: 5,
+				PRComment:                 false,
+			},
+			Output:      []string{"Input pr_comment overrides config file .github/required-checks.yaml"},
+			AssertError: assert.NoError,
+		},
+		"FetchedFromBaseRef": {
+			Contents: &mockContentsClient{Files: map[string]string{
+				"RoryQ/required-checks/.github/required-checks.yaml@f95f852bd8fca8fcc58a9a2d6c842781e32a215e": "required_workflow_patterns: [from-api]",
+			}},
+			Expected: &Config{
+				RequiredWorkflowPatterns:  NewPatterns("from-api"),
+				PollFrequency:             PollFrequencyDefault,
+				MissingRequiredRetryCount: MissingRequiredRetryCountDefault,
+			},
+			Output:      []string{"Fetching config file .github/required-checks.yaml from RoryQ/required-checks@f95f852bd8fca8fcc58a9a2d6c842781e32a215e"},
+			AssertError: assert.NoError,
+		},
+		"DefaultPathNotFound": {
+			Contents: &mockContentsClient{},
+			Expected: &Config{
+				PollFrequency:             PollFrequencyDefault,
+				MissingRequiredRetryCount: MissingRequiredRetryCountDefault,
+			},
+			AssertError: assert.NoError,
+		},
+		"ExplicitPathNotFound": {
+			Inputs:      []string{inputs.ConfigPath, "missing.yaml"},
+			Contents:    &mockContentsClient{},
+			AssertError: xassert.ErrorContains("config file not found: missing.yaml"),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			action, output := setupActionWithEnv("pull-request.opened", tt.Env, tt.Inputs...)
+
+			var contents ContentsClient
+			if tt.Contents != nil {
+				contents = tt.Contents
+			}
+			config, err := ConfigFromInputs(context.Background(), action, contents)
+			tt.AssertError(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.Expected.RequiredWorkflowPatterns, config.RequiredWorkflowPatterns)
+			assert.Equal(t, tt.Expected.PollFrequency, config.PollFrequency)
+			assert.Equal(t, tt.Expected.MissingRequiredRetryCount, config.MissingRequiredRetryCount)
+			assert.Equal(t, tt.Expected.PRComment, config.PRComment)
+			for _, line := range tt.Output {
+				assert.Contains(t, output.String(), line)
+			}
+		})
+	}
+}
+
+// TestActionInputDefaults checks that the inputs that override the config file have no default, as GitHub passes the
+// default of every input to the action, which would always override the config file.
+func TestActionInputDefaults(t *testing.T) {
+	b, err := os.ReadFile("../../action.yaml")
+	require.NoError(t, err)
+	var action struct {
+		Inputs map[string]struct {
+			Default *string `yaml:"default"`
+		} `yaml:"inputs"`
+	}
+	require.NoError(t, yaml.Unmarshal(b, &action))
+
+	for _, input := range configInputs {
+		name := strings.ToLower(input)
+		require.Contains(t, action.Inputs, name)
+		assert.Nil(t, action.Inputs[name].Default, "input %s has a default", name)
+	}
+}
+
+func TestConfigFromInputs_BaseConfigSource(t *testing.T) {
+	const (
+		baseSHA = "f95f852bd8fca8fcc58a9a2d6c842781e32a215e"
+		headSHA = "ec26c3e57ca3a959ca5aad62de7213c562f8c821"
+	)
+	const baseFile = "required_workflow_patterns: [tests, lint]\ntimeout: 30m"
+
+	tests := map[string]struct {
+		Inputs []string
+		// HeadFile is the config file at the head, defaulting to one that drifts from the base.
+		HeadFile    string
+		NoBaseFile  bool
+		Output      []string
+		AssertError assert.ErrorAssertionFunc
+	}{
+		"WarnsOnDrift": {
+			Inputs: []string{inputs.ConfigSource, ConfigSourceBase},
+			Output: []string{
+				"::warning::Config file .github/required-checks.yaml differs from the base ref: required_workflow_patterns changed from [tests, lint] to [tests]",
+				"Config precedence: inputs > config file .github/required-checks.yaml@" + baseSHA + " > defaults",
+			},
+			AssertError: assert.NoError,
+		},
+		"FailsOnDrift": {
+			Inputs:      []string{inputs.ConfigSource, ConfigSourceBase, inputs.ConfigDrift, ConfigDriftFail},
+			AssertError: xassert.ErrorContains("config file .github/required-checks.yaml differs from the base ref: required_workflow_patterns changed from [tests, lint] to [tests]"),
+		},
+		"IgnoresDrift": {
+			Inputs:      []string{inputs.ConfigSource, ConfigSourceBase, inputs.ConfigDrift, ConfigDriftIgnore},
+			AssertError: assert.NoError,
+		},
+		"IgnoresInputsThatRelaxBase": {
+			Inputs: []string{
+				inputs.ConfigSource, ConfigSourceBase,
+				inputs.RequiredWorkflowPatterns, "[tests]",
+				inputs.ConclusionPolicy, "failure: pass",
+			},
+			Output: []string{
+				"::warning::Ignoring inputs required_workflow_patterns, conclusion_policy cannot override the config file .github/required-checks.yaml@" + baseSHA,
+			},
+			AssertError: assert.NoError,
+		},
+		"FailsOnInputsThatRelaxBase": {
+			Inputs: []string{
+				inputs.ConfigSource, ConfigSourceBase,
+				inputs.ConfigDrift, ConfigDriftFail,
+				inputs.ConclusionPolicy, "failure: pass",
+			},
+			HeadFile:    baseFile,
+			AssertError: xassert.ErrorContains("inputs conclusion_policy cannot override the config file .github/required-checks.yaml@" + baseSHA),
+		},
+		"WarnsOnMissingBaseFile": {
+			Inputs: []string{
+				inputs.ConfigSource, ConfigSourceBase,
+				inputs.RequiredWorkflowPatterns, "[tests, lint]",
+				inputs.Timeout, "30m",
+			},
+			NoBaseFile: true,
+			Output: []string{
+				"::warning::Config source is base, but config file .github/required-checks.yaml not found at the base ref " + baseSHA + ", so the pattern and policy inputs are read from the pull request",
+			},
+			AssertError: assert.NoError,
+		},
+		"FailsOnMissingBaseFile": {
+			Inputs:      []string{inputs.ConfigSource, ConfigSourceBase, inputs.ConfigDrift, ConfigDriftFail},
+			NoBaseFile:  true,
+			AssertError: xassert.ErrorContains("config file .github/required-checks.yaml not found at the base ref " + baseSHA),
+		},
+		"InvalidSource": {
+			Inputs:      []string{inputs.ConfigSource, "main"},
+			AssertError: xassert.ErrorContains(`invalid config source "main"`),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			headFile := tt.HeadFile
+			if headFile == "" {
+				headFile = "required_workflow_patterns: [tests]\ntimeout: 30m"
+			}
+			contents := &mockContentsClient{Files: map[string]string{
+				"RoryQ/required-checks/.github/required-checks.yaml@" + baseSHA: baseFile,
+				"RoryQ/required-checks/.github/required-checks.yaml@" + headSHA: headFile,
+			}}
+			if tt.NoBaseFile {
+				delete(contents.Files, "RoryQ/required-checks/.github/required-checks.yaml@"+baseSHA)
+			}
+			action, output := setupAction("pull-request.opened", tt.Inputs...)
+
+			config, err := ConfigFromInputs(context.Background(), action, contents)
+			tt.AssertError(t, err)
+			for _, line := range tt.Output {
+				assert.Contains(t, output.String(), line)
+			}
+			if err != nil {
+				return
+			}
+			assert.Equal(t, NewPatterns("tests", "lint"), config.RequiredWorkflowPatterns)
+			assert.Empty(t, config.ConclusionPolicy)
+			assert.Equal(t, 30*time.Minute, config.Timeout)
+		})
+	}
+}
+
+func TestDiffConfigFiles(t *testing.T) {
+	base, err := ParseConfigFile([]byte(`
+required_workflow_patterns:
+  - pattern: security-scan
+    allowed_conclusions: [success]
+conclusion_policy:
+  skipped: fail
+`))
+	require.NoError(t, err)
+	head, err := ParseConfigFile([]byte(`
+required_workflow_patterns:
+  - security-scan
+poll_frequency_seconds: 5
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"conclusion_policy changed from {skipped: fail} to <none>",
+		"poll_frequency_seconds changed from <none> to 5",
+		"required_workflow_patterns changed from [{pattern: security-scan, allowed_conclusions: [success]}] to [security-scan]",
+	}, diffConfigFiles(base, head))
+	assert.Empty(t, diffConfigFiles(base, base))
+	assert.Equal(t, []string{"config file removed"}, diffConfigFiles(base, nil))
+}
+
+func TestConfigFromInputs_Extends(t *testing.T) {
+	const baseSHA = "f95f852bd8fca8fcc58a9a2d6c842781e32a215e"
+	contents := &mockContentsClient{Files: map[string]string{
+		"RoryQ/required-checks/.github/required-checks.yaml@" + baseSHA: `
+extends: RoryQ/policy/required-checks.yaml@main
+required_workflow_patterns:
+  - pattern: security-scan
+    optional: true
+  - tests
+timeout: 1h
+`,
+		"RoryQ/policy/required-checks.yaml@main": `
+required_workflow_patterns:
+  - pattern: security-scan
+    app: github-advanced-security
+timeout: 30m
+`,
+	}}
+	action, output := setupAction("pull-request.opened")
+
+	config, err := ConfigFromInputs(context.Background(), action, contents)
+
+	require.NoError(t, err)
+	assert.Equal(t, []Pattern{
+		{Pattern: "security-scan", App: StringList{"github-advanced-security"}},
+		{Pattern: "tests"},
+	}, config.RequiredWorkflowPatterns)
+	assert.Equal(t, 30*time.Minute, config.Timeout)
+	assert.Contains(t, output.String(), "Extending config file RoryQ/policy/required-checks.yaml@main")
+	assert.Contains(t, output.String(), `::warning::Cannot relax inherited pattern "security-scan": ignoring optional`)
+	assert.Contains(t, output.String(), "::warning::Cannot relax inherited timeout: ignoring 1h0m0s")
+	assert.Contains(t, output.String(), "Effective config:")
+}
+
+// mockContentsClient returns the files keyed by owner/repo/path@ref, or a not found error.
+type mockContentsClient struct {
+	Files map[string]string
+}
+
+func (m *mockContentsClient) GetContents(_ context.Context, owner, repo, path, ref string) ([]byte, error) {
+	if content, ok := m.Files[fmt.Sprintf("%s/%s/%s@%s", owner, repo, path, ref)]; ok {
+		return []byte(content), nil
+	}
+	return nil, &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+}
+
 func TestDefaultTargetSHA_FromInput(t *testing.T) {
 	action, _ := setupAction("pull-request.opened", inputs.TargetSHA, "input-sha")
 
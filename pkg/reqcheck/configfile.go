@@ -0,0 +1,418 @@
+package reqcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/sethvargo/go-githubactions"
+	"gopkg.in/yaml.v3"
+
+	"github.com/roryq/required-checks/pkg/reqcheck/inputs"
+)
+
+// DefaultConfigPath is the config file read from the repository when the config path input is not set.
+const DefaultConfigPath = ".github/required-checks.yaml"
+
+// ContentsClient gets the contents of files in a repository.
+type ContentsClient interface {
+	GetContents(ctx context.Context, owner, repo, path, ref string) ([]byte, error)
+}
+
+// ConfigFile is the yaml config file, using the same keys and values as the action inputs:
+//
+//	required_workflow_patterns:
+//	  - tests
+//	conditional_path_workflow_patterns:
+//	  "**/*.go": [go-unit-tests]
+//	poll_frequency_seconds: 30
+//	timeout: 30m
+//
+// A config file can extend a config file in another repository with extends: owner/repo/path@ref. The inherited
+// patterns cannot be removed or relaxed.
+type ConfigFile struct {
+	Extends                         string              `yaml:"extends,omitempty"`
+	RequiredWorkflowPatterns        []Pattern           `yaml:"required_workflow_patterns,omitempty"`
+	ConditionalPathWorkflowPatterns map[string]PathRule `yaml:"conditional_path_workflow_patterns,omitempty"`
+	CheckApps                       map[string][]string `yaml:"check_apps,omitempty"`
+	ConclusionPolicy                ConclusionPolicy    `yaml:"conclusion_policy,omitempty"`
+	InitialDelaySeconds             *int                `yaml:"initial_delay_seconds,omitempty"`
+	PollFrequencySeconds            *int                `yaml:"poll_frequency_seconds,omitempty"`
+	MissingRequiredRetryCount       *int                `yaml:"missing_required_retry_count,omitempty"`
+	Timeout                         string              `yaml:"timeout,omitempty"`
+	CheckRunName                    string              `yaml:"check_run_name,omitempty"`
+	PRComment                       *bool               `yaml:"pr_comment,omitempty"`
+	GitChangedFiles                 *bool               `yaml:"git_changed_files,omitempty"`
+}
+
+// ConfigFileFrom returns the config file that defines the config.
+func ConfigFileFrom(c *Config) *ConfigFile {
+	initialDelay := int(c.InitialDelay.Seconds())
+	pollFrequency := int(c.PollFrequency.Seconds())
+	f := &ConfigFile{
+		RequiredWorkflowPatterns:        c.RequiredWorkflowPatterns,
+		ConditionalPathWorkflowPatterns: c.ConditionalPathWorkflowPatterns,
+		CheckApps:                       c.CheckApps,
+		ConclusionPolicy:                c.ConclusionPolicy,
+		InitialDelaySeconds:             &initialDelay,
+		PollFrequencySeconds:            &pollFrequency,
+		MissingRequiredRetryCount:       &c.MissingRequiredRetryCount,
+		CheckRunName:                    c.CheckRunName,
+		PRComment:                       &c.PRComment,
+		GitChangedFiles:                 &c.GitChangedFiles,
+	}
+	if c.Timeout > 0 {
+		f.Timeout = c.Timeout.String()
+	}
+	return f
+}
+
+// String returns the config file as yaml.
+func (f *ConfigFile) String() string {
+	b, err := yaml.Marshal(f)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// ReadConfigFile reads the config file at path.
+func ReadConfigFile(path string) (*ConfigFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfigFile(b)
+}
+
+// ParseConfigFile validates and parses the yaml config file contents.
+func ParseConfigFile(b []byte) (*ConfigFile, error) {
+	if err := ValidateConfigFile(b).err(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+	f := new(ConfigFile)
+	if err := yaml.Unmarshal(b, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Apply overrides the config with the values that are set in the file.
+func (f *ConfigFile) Apply(c *Config) error {
+	if f.RequiredWorkflowPatterns != nil {
+		c.RequiredWorkflowPatterns = f.RequiredWorkflowPatterns
+	}
+	if f.ConditionalPathWorkflowPatterns != nil {
+		c.ConditionalPathWorkflowPatterns = f.ConditionalPathWorkflowPatterns
+	}
+	if f.CheckApps != nil {
+		c.CheckApps = f.CheckApps
+	}
+	if f.ConclusionPolicy != nil {
+		c.ConclusionPolicy = f.ConclusionPolicy
+	}
+	if f.InitialDelaySeconds != nil {
+		c.InitialDelay = time.Duration(*f.InitialDelaySeconds) * time.Second
+	}
+	if f.PollFrequencySeconds != nil {
+		c.PollFrequency = time.Duration(*f.PollFrequencySeconds) * time.Second
+	}
+	if f.MissingRequiredRetryCount != nil {
+		c.MissingRequiredRetryCount = *f.MissingRequiredRetryCount
+	}
+	if f.Timeout != "" {
+		t, err := parseDuration(f.Timeout)
+		if err != nil {
+			return err
+		}
+		c.Timeout = t
+	}
+	if f.CheckRunName != "" {
+		c.CheckRunName = f.CheckRunName
+	}
+	if f.PRComment != nil {
+		c.PRComment = *f.PRComment
+	}
+	if f.GitChangedFiles != nil {
+		c.GitChangedFiles = *f.GitChangedFiles
+	}
+	return nil
+}
+
+// Config sources: head reads the config file from the local checkout, falling back to the base ref, and base always
+// reads it from the base ref so that a pull request cannot weaken its own required checks.
+const (
+	ConfigSourceHead = "head"
+	ConfigSourceBase = "base"
+)
+
+// Config drift: how a difference between the head and base config files is reported when reading from the base.
+const (
+	ConfigDriftIgnore = "ignore"
+	ConfigDriftWarn   = "warn"
+	ConfigDriftFail   = "fail"
+)
+
+// loadConfigFile reads the config file from the configured source. It returns nil if the file does not exist, unless
+// a path other than the default was set by the config path input. The returned source describes where the file was
+// read from.
+func loadConfigFile(ctx context.Context, action *githubactions.Action, env Environment, contents ContentsClient) (*ConfigFile, string, error) {
+	path := action.GetInput(inputs.ConfigPath)
+	explicit := path != "" && path != DefaultConfigPath
+	if path == "" {
+		path = DefaultConfigPath
+	}
+
+	var (
+		file   *ConfigFile
+		source string
+		err    error
+	)
+	switch configSource := action.GetInput(inputs.ConfigSource); configSource {
+	case "", ConfigSourceHead:
+		file, source, err = loadHeadConfigFile(ctx, action, env, contents, path)
+	case ConfigSourceBase:
+		file, source, err = loadBaseConfigFile(ctx, action, env, contents, path)
+	default:
+		return nil, "", fmt.Errorf("invalid config source %q, must be %q or %q", configSource, ConfigSourceHead, ConfigSourceBase)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if file == nil {
+		if explicit {
+			return nil, "", fmt.Errorf("config file not found: %s", path)
+		}
+		action.Debugf("No config file found: %s", path)
+	}
+	return file, source, nil
+}
+
+// loadHeadConfigFile reads the config file from the local checkout if it exists, otherwise fetches it from the
+// repository at the base ref.
+func loadHeadConfigFile(ctx context.Context, action *githubactions.Action, env Environment, contents ContentsClient, path string) (*ConfigFile, string, error) {
+	if file, err := readLocalConfigFile(action, path); file != nil || err != nil {
+		return file, fmt.Sprintf("config file %s", path), err
+	}
+	return fetchConfigFile(ctx, action, env, contents, path, configRef(env))
+}
+
+// loadBaseConfigFile fetches the config file from the repository at the base sha, and reports any difference from
+// the config file at the head.
+func loadBaseConfigFile(ctx context.Context, action *githubactions.Action, env Environment, contents ContentsClient, path string) (*ConfigFile, string, error) {
+	if !canReadBaseConfig(env, contents) {
+		action.Warningf("Cannot read config file from the base ref for event %q, reading from the head", env.EventName)
+		return loadHeadConfigFile(ctx, action, env, contents, path)
+	}
+
+	drift, err := configDrift(action)
+	if err != nil {
+		return nil, "", err
+	}
+	base, source, err := fetchConfigFile(ctx, action, env, contents, path, env.BaseSHA)
+	if err != nil {
+		return nil, "", err
+	}
+	if base == nil {
+		// Without a config file at the base, the inputs set by the pull request's workflow cannot be protected.
+		msg := fmt.Sprintf("config file %s not found at the base ref %s, so the pattern and policy inputs are read from the pull request", path, env.BaseSHA)
+		switch drift {
+		case ConfigDriftFail:
+			return nil, "", errors.New(msg)
+		case ConfigDriftWarn:
+			action.Warningf("Config source is base, but %s", msg)
+		default:
+			action.Infof("Config source is base, but %s", msg)
+		}
+		return nil, "", nil
+	}
+	if drift == ConfigDriftIgnore {
+		return base, source, nil
+	}
+
+	head, err := readLocalConfigFile(action, path)
+	if err != nil {
+		return nil, "", err
+	}
+	if head == nil {
+		head, _, err = fetchConfigFile(ctx, action, env, contents, path, env.HeadSHA)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	diffs := diffConfigFiles(base, head)
+	if len(diffs) == 0 {
+		return base, source, nil
+	}
+	for _, d := range diffs {
+		action.Warningf("Config file %s differs from the base ref: %s", path, d)
+	}
+	if drift == ConfigDriftFail {
+		return nil, "", fmt.Errorf("config file %s differs from the base ref: %s", path, strings.Join(diffs, "; "))
+	}
+	return base, source, nil
+}
+
+// canReadBaseConfig reports whether the config file can be fetched from the base ref of the event.
+func canReadBaseConfig(env Environment, contents ContentsClient) bool {
+	return env.BaseSHA != "" && contents != nil
+}
+
+// configDrift returns the config drift input, defaulting to warn.
+func configDrift(action *githubactions.Action) (string, error) {
+	switch drift := action.GetInput(inputs.ConfigDrift); drift {
+	case "":
+		return ConfigDriftWarn, nil
+	case ConfigDriftIgnore, ConfigDriftWarn, ConfigDriftFail:
+		return drift, nil
+	default:
+		return "", fmt.Errorf("invalid config drift %q, must be one of %q", drift, []string{ConfigDriftIgnore, ConfigDriftWarn, ConfigDriftFail})
+	}
+}
+
+// baseConfigInputs are the inputs that cannot override a config file read from the base ref, as a pull request can
+// change them in its own workflow file.
+var baseConfigInputs = []string{
+	inputs.RequiredWorkflowPatterns,
+	inputs.ConditionalPathWorkflowPatterns,
+	inputs.CheckApps,
+	inputs.ConclusionPolicy,
+}
+
+// baseConfigGetInput returns the inputs, without the inputs that cannot override the config file read from the base
+// ref. Setting them is reported as drift: ignored, warned about, or failing with config_drift: fail.
+func baseConfigGetInput(action *githubactions.Action, source string) (func(name string) string, error) {
+	drift, err := configDrift(action)
+	if err != nil {
+		return nil, err
+	}
+
+	var set []string
+	for _, name := range baseConfigInputs {
+		if action.GetInput(name) != "" {
+			set = append(set, strings.ToLower(name))
+		}
+	}
+	if len(set) > 0 {
+		msg := fmt.Sprintf("inputs %s cannot override the %s", strings.Join(set, ", "), source)
+		switch drift {
+		case ConfigDriftFail:
+			return nil, errors.New(msg)
+		case ConfigDriftWarn:
+			action.Warningf("Ignoring %s", msg)
+		default:
+			action.Infof("Ignoring %s", msg)
+		}
+	}
+
+	return func(name string) string {
+		if slices.Contains(baseConfigInputs, name) {
+			return ""
+		}
+		return action.GetInput(name)
+	}, nil
+}
+
+// readLocalConfigFile reads the config file from the local checkout, returning nil if it does not exist.
+func readLocalConfigFile(action *githubactions.Action, path string) (*ConfigFile, error) {
+	b, err := os.ReadFile(filepath.Join(action.Getenv("GITHUB_WORKSPACE"), path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	action.Infof("Reading config file from local checkout: %s", path)
+	return ParseConfigFile(b)
+}
+
+// fetchConfigFile fetches the config file from the repository at ref, returning nil if it does not exist.
+func fetchConfigFile(ctx context.Context, action *githubactions.Action, env Environment, contents ContentsClient, path, ref string) (*ConfigFile, string, error) {
+	if contents == nil {
+		return nil, "", nil
+	}
+	action.Infof("Fetching config file %s from %s/%s@%s", path, env.Owner, env.Repo, ref)
+	b, err := contents.GetContents(ctx, env.Owner, env.Repo, path, ref)
+	if isNotFoundError(err) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := ParseConfigFile(b)
+	return file, fmt.Sprintf("config file %s@%s", path, ref), err
+}
+
+// configRef is the base ref of a pull request, otherwise the commit being checked.
+func configRef(env Environment) string {
+	if env.PRNumber != 0 && env.BaseSHA != "" {
+		return env.BaseSHA
+	}
+	return env.HeadSHA
+}
+
+// diffConfigFiles describes each top level key that differs between the base and head config files.
+func diffConfigFiles(base, head *ConfigFile) []string {
+	if base == nil && head == nil {
+		return nil
+	}
+	if base == nil {
+		return []string{"config file added"}
+	}
+	if head == nil {
+		return []string{"config file removed"}
+	}
+
+	baseValues, headValues := configFileValues(base), configFileValues(head)
+	keys := lo.Uniq(append(lo.Keys(baseValues), lo.Keys(headValues)...))
+	slices.Sort(keys)
+
+	var diffs []string
+	for _, key := range keys {
+		if baseValues[key] != headValues[key] {
+			diffs = append(diffs, fmt.Sprintf("%s changed from %s to %s", key, orNone(baseValues[key]), orNone(headValues[key])))
+		}
+	}
+	return diffs
+}
+
+// configFileValues returns the flow style yaml of each key that is set in the config file.
+func configFileValues(f *ConfigFile) map[string]string {
+	var node yaml.Node
+	if err := node.Encode(f); err != nil {
+		return nil
+	}
+	values := map[string]string{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		setFlowStyle(node.Content[i+1])
+		b, err := yaml.Marshal(node.Content[i+1])
+		if err != nil {
+			continue
+		}
+		values[node.Content[i].Value] = strings.TrimSpace(string(b))
+	}
+	return values
+}
+
+func setFlowStyle(node *yaml.Node) {
+	node.Style |= yaml.FlowStyle
+	for _, n := range node.Content {
+		setFlowStyle(n)
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
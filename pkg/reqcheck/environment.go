@@ -0,0 +1,118 @@
+package reqcheck
+
+import (
+	"strings"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+// Environment describes the repository, workflow run, and event that the checks are required for.
+type Environment struct {
+	Owner string
+	Repo  string
+	// EventName is the event that triggered the run, e.g. pull_request, merge_group or push.
+	EventName string
+	// RunID and Job identify the workflow job waiting for the checks, so that it can skip waiting for itself.
+	RunID int64
+	Job   string
+	// PRNumber is the pull request number, or zero if the event is not for a pull request.
+	PRNumber int
+	// BaseSHA and HeadSHA are the commits being compared by the event, if any.
+	BaseSHA string
+	HeadSHA string
+	// DefaultBranch is the repository's default branch, compared against by a push that creates a branch or tag.
+	DefaultBranch string
+}
+
+// EnvironmentFromAction reads the environment from the GitHub Actions context.
+func EnvironmentFromAction(action *githubactions.Action) (Environment, error) {
+	ghCtx, err := action.Context()
+	if err != nil {
+		return Environment{}, err
+	}
+
+	owner, repo := ghCtx.Repo()
+	env := Environment{
+		Owner:     owner,
+		Repo:      repo,
+		EventName: ghCtx.EventName,
+		RunID:     ghCtx.RunID,
+		Job:       ghCtx.Job,
+		HeadSHA:   ghCtx.SHA,
+	}
+
+	setEventCommits(&env, ghCtx.Event)
+	return env, nil
+}
+
+// EnvironmentFromEvent reads the repository, pull request and commits from an event payload, e.g. a saved event JSON.
+// The payload does not include the event name, so it is inferred from the payload.
+func EnvironmentFromEvent(event map[string]any) Environment {
+	var env Environment
+	env.Owner, env.Repo, _ = strings.Cut(getString(event, "repository", "full_name"), "/")
+	switch {
+	case event["pull_request"] != nil:
+		env.EventName = "pull_request"
+	case event["merge_group"] != nil:
+		env.EventName = "merge_group"
+	case event["check_run"] != nil:
+		env.EventName = "check_run"
+	case event["after"] != nil:
+		env.EventName = "push"
+	}
+	setEventCommits(&env, event)
+
+	// A check run event lists the pull requests of its head commit.
+	if env.EventName == "check_run" {
+		checkRun, _ := event["check_run"].(map[string]any)
+		env.HeadSHA = getString(checkRun, "head_sha")
+		if prs, _ := checkRun["pull_requests"].([]any); len(prs) > 0 {
+			pr, _ := prs[0].(map[string]any)
+			env.PRNumber = int(getFloat(pr, "number"))
+			env.BaseSHA = getString(pr, "base", "sha")
+		}
+	}
+	return env
+}
+
+// setEventCommits sets the pull request and the commits compared by the event.
+func setEventCommits(env *Environment, event map[string]any) {
+	switch {
+	case event["pull_request"] != nil:
+		pr, _ := event["pull_request"].(map[string]any)
+		env.PRNumber = int(getFloat(pr, "number"))
+		env.BaseSHA = getString(pr, "base", "sha")
+		env.HeadSHA = getString(pr, "head", "sha")
+	case event["merge_group"] != nil:
+		mg, _ := event["merge_group"].(map[string]any)
+		env.BaseSHA = getString(mg, "base_sha")
+		env.HeadSHA = getString(mg, "head_sha")
+	case env.EventName == "push":
+		env.BaseSHA = getString(event, "before")
+		env.HeadSHA = getString(event, "after")
+		env.DefaultBranch = getString(event, "repository", "default_branch")
+	}
+}
+
+// getString returns the string at the path of keys in the event, or empty if it is not found.
+func getString(event map[string]any, keys ...string) string {
+	s, _ := getPath(event, keys...).(string)
+	return s
+}
+
+func getFloat(event map[string]any, keys ...string) float64 {
+	f, _ := getPath(event, keys...).(float64)
+	return f
+}
+
+func getPath(event map[string]any, keys ...string) any {
+	var v any = event
+	for _, key := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
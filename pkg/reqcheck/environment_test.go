@@ -0,0 +1,103 @@
+package reqcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentFromAction(t *testing.T) {
+	testCases := map[string]struct {
+		event    string
+		env      map[string]string
+		expected Environment
+	}{
+		"pull request": {
+			event: "pull-request.opened",
+			expected: Environment{
+				Owner:     "RoryQ",
+				Repo:      "required-checks",
+				EventName: "",
+				RunID:     12345,
+				PRNumber:  2,
+				BaseSHA:   "f95f852bd8fca8fcc58a9a2d6c842781e32a215e",
+				HeadSHA:   "ec26c3e57ca3a959ca5aad62de7213c562f8c821",
+			},
+		},
+		"push": {
+			event: "push",
+			env:   map[string]string{"GITHUB_EVENT_NAME": "push", "GITHUB_SHA": "ec26c3e57ca3a959ca5aad62de7213c562f8c821"},
+			expected: Environment{
+				Owner:         "RoryQ",
+				Repo:          "required-checks",
+				EventName:     "push",
+				RunID:         12345,
+				BaseSHA:       "6113728f27ae82c7b1a177c8d03f9e96e0adf246",
+				HeadSHA:       "ec26c3e57ca3a959ca5aad62de7213c562f8c821",
+				DefaultBranch: "main",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			action, _ := setupActionWithEnv(tc.event, tc.env)
+
+			env, err := EnvironmentFromAction(action)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, env)
+		})
+	}
+}
+
+func TestEnvironmentFromEvent(t *testing.T) {
+	testCases := map[string]struct {
+		event    map[string]any
+		expected Environment
+	}{
+		"pull request": {
+			event: map[string]any{
+				"repository": map[string]any{"full_name": "RoryQ/required-checks"},
+				"pull_request": map[string]any{
+					"number": float64(2),
+					"base":   map[string]any{"sha": "base-sha"},
+					"head":   map[string]any{"sha": "head-sha"},
+				},
+			},
+			expected: Environment{Owner: "RoryQ", Repo: "required-checks", EventName: "pull_request", PRNumber: 2, BaseSHA: "base-sha", HeadSHA: "head-sha"},
+		},
+		"push": {
+			event: map[string]any{
+				"repository": map[string]any{"full_name": "RoryQ/required-checks"},
+				"before":     "before-sha",
+				"after":      "after-sha",
+			},
+			expected: Environment{Owner: "RoryQ", Repo: "required-checks", EventName: "push", BaseSHA: "before-sha", HeadSHA: "after-sha"},
+		},
+		"check run": {
+			event: map[string]any{
+				"repository": map[string]any{"full_name": "RoryQ/required-checks"},
+				"check_run": map[string]any{
+					"name":     "tests",
+					"head_sha": "head-sha",
+					"pull_requests": []any{
+						map[string]any{"number": float64(2), "base": map[string]any{"sha": "base-sha"}},
+					},
+				},
+			},
+			expected: Environment{Owner: "RoryQ", Repo: "required-checks", EventName: "check_run", PRNumber: 2, BaseSHA: "base-sha", HeadSHA: "head-sha"},
+		},
+		"unknown event": {
+			event:    map[string]any{"repository": map[string]any{"full_name": "RoryQ/required-checks"}},
+			expected: Environment{Owner: "RoryQ", Repo: "required-checks"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, EnvironmentFromEvent(tc.event))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package reqcheck
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeoutError is returned when required checks are still waiting after the global timeout, or after the timeout of
+// the rule they matched.
+type TimeoutError struct {
+	// Timeout is the global timeout, or zero if the checks exceeded their rule's timeout.
+	Timeout time.Duration
+	// Pending are the checks that had not completed.
+	Pending []PendingCheck
+	// Missing are the patterns that had not matched any checks.
+	Missing []string
+}
+
+// PendingCheck is a check that was still queued or in progress.
+type PendingCheck struct {
+	Name    string
+	Pattern string
+	Status  string
+	Waiting time.Duration
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	msg := "required checks timed out"
+	if e.Timeout > 0 {
+		msg += fmt.Sprintf(" after %s", e.Timeout)
+	}
+
+	details := make([]string, 0, len(e.Pending)+1)
+	for _, p := range e.Pending {
+		detail := fmt.Sprintf("%q %s for %s", p.Name, p.Status, p.Waiting.Round(time.Second))
+		if e.Timeout == 0 && p.Timeout > 0 {
+			detail += fmt.Sprintf(" (timeout %s)", p.Timeout)
+		}
+		details = append(details, detail)
+	}
+	if len(e.Missing) > 0 {
+		details = append(details, fmt.Sprintf("not found %q", e.Missing))
+	}
+	if len(details) == 0 {
+		return msg
+	}
+	return msg + ": " + strings.Join(details, ", ")
+}
+
+// MissingChecksError is returned when required patterns have not matched enough checks after retrying.
+type MissingChecksError struct {
+	// Missing are the patterns that had not matched enough checks.
+	Missing []MissingPattern
+}
+
+// MissingPattern is a required pattern that had not matched enough checks.
+type MissingPattern struct {
+	Pattern     string
+	Description string
+	// Found is the number of checks that matched the pattern, and MinCount the number required.
+	Found    int
+	MinCount int
+}
+
+func (e *MissingChecksError) Error() string {
+	names := make([]string, 0, len(e.Missing))
+	for _, m := range e.Missing {
+		names = append(names, m.Pattern)
+	}
+	return fmt.Sprintf("required checks not found: %q", sortStrings(names))
+}
+
+// FailedChecksError is returned when required checks complete with a conclusion that fails.
+type FailedChecksError struct {
+	Failed []FailedCheck
+}
+
+// FailedCheck is a check that completed with a conclusion that fails.
+type FailedCheck struct {
+	Name       string
+	Pattern    string
+	Conclusion string
+	DetailsURL string
+}
+
+func (e *FailedChecksError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		names = append(names, f.Name)
+	}
+	return fmt.Sprintf("required checks failed: %q", names)
+}
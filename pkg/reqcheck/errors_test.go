@@ -0,0 +1,40 @@
+package reqcheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutError_Error(t *testing.T) {
+	testCases := map[string]struct {
+		err      *TimeoutError
+		expected string
+	}{
+		"no details": {
+			err:      &TimeoutError{Timeout: 30 * time.Minute},
+			expected: "required checks timed out after 30m0s",
+		},
+		"pending": {
+			err: &TimeoutError{
+				Timeout: 30 * time.Minute,
+				Pending: []PendingCheck{{Name: "tests", Status: StatusInProgress, Waiting: 31 * time.Minute}},
+			},
+			expected: `required checks timed out after 30m0s: "tests" in_progress for 31m0s`,
+		},
+		"rule timeout and missing": {
+			err: &TimeoutError{
+				Pending: []PendingCheck{{Name: "tests", Status: StatusQueued, Waiting: 2 * time.Minute, Timeout: time.Minute}},
+				Missing: []string{"lint"},
+			},
+			expected: `required checks timed out: "tests" queued for 2m0s (timeout 1m0s), not found ["lint"]`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.err.Error())
+		})
+	}
+}
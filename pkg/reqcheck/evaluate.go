@@ -0,0 +1,171 @@
+package reqcheck
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// Snapshot is the checks reported against the target sha at a point in time.
+type Snapshot struct {
+	Checks []Check
+	// Now is when the snapshot was taken, to compare against the rule timeouts.
+	Now time.Time
+	// FirstSeen is when each check was first seen, keyed by checkKey, for checks without a start time. Optional.
+	FirstSeen map[string]time.Time
+}
+
+// Outcome of evaluating the required checks, or a single pattern.
+type Outcome string
+
+const (
+	OutcomePassed   Outcome = "passed"
+	OutcomeFailed   Outcome = "failed"
+	OutcomeMissing  Outcome = "missing"
+	OutcomeWaiting  Outcome = "waiting"
+	OutcomeTimedOut Outcome = "timed_out"
+)
+
+// Decision is the result of evaluating a snapshot of checks against the rules.
+type Decision struct {
+	// Outcome is missing if any required pattern has not matched enough checks, otherwise failed if any check failed,
+	// timed out if any check exceeded its rule's timeout, waiting if any check has not completed, otherwise passed.
+	Outcome Outcome
+	// Patterns is the state of each rule, in the order of the rules.
+	Patterns []PatternState
+	// Matches are the checks that matched a rule.
+	Matches []Match
+	// Missing are the rules that have not matched enough checks.
+	Missing []*Rule
+	// Failed, TimedOut and Waiting are the matched checks that failed, exceeded their rule's timeout, or have not
+	// completed.
+	Failed   []Match
+	TimedOut []Match
+	Waiting  []Match
+	// Suspicious are the checks that matched a rule by name, but were reported by an app the rule does not allow.
+	Suspicious []Match
+	// Skipped is the check of the job waiting for the checks, if it was found.
+	Skipped []Check
+}
+
+// PatternState is the state of a single rule and the checks it matched.
+type PatternState struct {
+	Rule    *Rule
+	Matches []Match
+	Outcome Outcome
+	// Explanation describes the outcome, e.g. which check failed and its conclusion.
+	Explanation string
+}
+
+// Evaluate decides whether the required checks in the snapshot have passed, failed, timed out, are missing, or are
+// still waiting. The check of the job in the environment is skipped, so that the job does not wait for itself.
+func Evaluate(snapshot Snapshot, rules Ruleset, env Environment) Decision {
+	var d Decision
+	foundSelf := false
+	for _, c := range snapshot.Checks {
+		// skip waiting for this check if this is named the same as another check
+		if !foundSelf && isSelf(c, env) {
+			foundSelf = true
+			d.Skipped = append(d.Skipped, c)
+			continue
+		}
+		found := rules.First(c)
+		if found == nil {
+			continue
+		}
+		if !found.AllowsApp(c) {
+			d.Suspicious = append(d.Suspicious, Match{Check: c, Rule: found})
+			continue
+		}
+		d.Matches = append(d.Matches, Match{Check: c, Rule: found})
+	}
+
+	for _, r := range rules {
+		state := evaluateRule(r, lo.Filter(d.Matches, func(item Match, _ int) bool { return item.Rule == r }), snapshot)
+		d.Patterns = append(d.Patterns, state)
+		if state.Outcome == OutcomeMissing {
+			d.Missing = append(d.Missing, r)
+		}
+	}
+
+	for _, m := range d.Matches {
+		switch {
+		case m.Rule.Failed(m.Check):
+			d.Failed = append(d.Failed, m)
+		case m.Rule.TimedOut(m.Check, waitingSince(m.Check, snapshot.FirstSeen), snapshot.Now):
+			d.TimedOut = append(d.TimedOut, m)
+			d.Waiting = append(d.Waiting, m)
+		case m.Rule.Waiting(m.Check):
+			d.Waiting = append(d.Waiting, m)
+		}
+	}
+
+	switch {
+	case len(d.Missing) > 0:
+		d.Outcome = OutcomeMissing
+	case len(d.Failed) > 0:
+		d.Outcome = OutcomeFailed
+	case len(d.TimedOut) > 0:
+		d.Outcome = OutcomeTimedOut
+	case len(d.Waiting) > 0:
+		d.Outcome = OutcomeWaiting
+	default:
+		d.Outcome = OutcomePassed
+	}
+	return d
+}
+
+// isSelf reports whether the check is the job in the environment.
+func isSelf(c Check, env Environment) bool {
+	return env.RunID != 0 &&
+		strings.Contains(c.GetDetailsURL(), fmt.Sprintf("runs/%d/job", env.RunID)) &&
+		c.GetName() == env.Job
+}
+
+func evaluateRule(r *Rule, matches []Match, snapshot Snapshot) PatternState {
+	state := PatternState{Rule: r, Matches: matches}
+	failed := lo.Filter(matches, func(item Match, _ int) bool { return r.Failed(item.Check) })
+	timedOut := lo.Filter(matches, func(item Match, _ int) bool {
+		return r.TimedOut(item.Check, waitingSince(item.Check, snapshot.FirstSeen), snapshot.Now)
+	})
+	waiting := lo.Filter(matches, func(item Match, _ int) bool { return r.Waiting(item.Check) })
+
+	switch {
+	case r.Missing(len(matches)):
+		state.Outcome = OutcomeMissing
+		state.Explanation = "no checks matched"
+		if len(matches) > 0 {
+			state.Explanation = fmt.Sprintf("found %d of %d required checks", len(matches), r.MinCount)
+		}
+		if r.Description != "" {
+			state.Explanation += ": " + r.Description
+		}
+	case len(failed) > 0:
+		state.Outcome = OutcomeFailed
+		state.Explanation = strings.Join(lo.Map(failed, func(item Match, _ int) string {
+			return fmt.Sprintf("%q concluded %s", item.GetName(), item.GetConclusion())
+		}), ", ")
+	case len(timedOut) > 0:
+		state.Outcome = OutcomeTimedOut
+		state.Explanation = strings.Join(lo.Map(timedOut, func(item Match, _ int) string {
+			return fmt.Sprintf("%q %s longer than timeout %s", item.GetName(), item.GetStatus(), r.Timeout)
+		}), ", ")
+	case len(waiting) > 0:
+		state.Outcome = OutcomeWaiting
+		state.Explanation = strings.Join(lo.Map(waiting, func(item Match, _ int) string {
+			if item.GetStatus() == StatusCompleted {
+				return fmt.Sprintf("%q concluded %s, waiting for a re-run", item.GetName(), item.GetConclusion())
+			}
+			return fmt.Sprintf("%q %s", item.GetName(), item.GetStatus())
+		}), ", ")
+	case len(matches) == 0:
+		state.Outcome = OutcomePassed
+		state.Explanation = "optional, no checks matched"
+	default:
+		state.Outcome = OutcomePassed
+		state.Explanation = fmt.Sprintf("all %d matched checks passed", len(matches))
+	}
+	return state
+}
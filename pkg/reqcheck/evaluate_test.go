@@ -0,0 +1,82 @@
+package reqcheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	env := Environment{RunID: 12345, Job: "required-checks"}
+	completed := func(name, conclusion string) Check {
+		return Check{CheckRun: &github.CheckRun{Name: github.String(name), Status: github.String(StatusCompleted), Conclusion: github.String(conclusion)}, Source: SourceCheckRun}
+	}
+	inProgress := func(name string, startedAt time.Time) Check {
+		return Check{CheckRun: &github.CheckRun{Name: github.String(name), Status: github.String(StatusInProgress), StartedAt: &github.Timestamp{Time: startedAt}}, Source: SourceCheckRun}
+	}
+	self := Check{CheckRun: &github.CheckRun{
+		Name:       github.String("required-checks"),
+		Status:     github.String(StatusInProgress),
+		DetailsURL: github.String("https://github.com/RoryQ/required-checks/actions/runs/12345/job/1"),
+	}, Source: SourceCheckRun}
+
+	testCases := map[string]struct {
+		patterns             []Pattern
+		checks               []Check
+		expectedOutcome      Outcome
+		expectedExplanations []string
+	}{
+		"passed": {
+			patterns:             []Pattern{{Pattern: "build"}, {Pattern: "docs", Optional: true}},
+			checks:               []Check{completed("build", ConclusionSuccess), completed("build (arm64)", ConclusionSkipped)},
+			expectedOutcome:      OutcomePassed,
+			expectedExplanations: []string{"all 2 matched checks passed", "optional, no checks matched"},
+		},
+		"skips self": {
+			patterns:             NewPatterns("required-check"),
+			checks:               []Check{self, completed("required-check-1", ConclusionSuccess)},
+			expectedOutcome:      OutcomePassed,
+			expectedExplanations: []string{"all 1 matched checks passed"},
+		},
+		"missing before failed": {
+			patterns:             []Pattern{{Pattern: "build"}, {Pattern: "tests", MinCount: 2, Description: "one per go version"}},
+			checks:               []Check{completed("build", ConclusionFailure), completed("tests (1.22)", ConclusionSuccess)},
+			expectedOutcome:      OutcomeMissing,
+			expectedExplanations: []string{`"build" concluded failure`, "found 1 of 2 required checks: one per go version"},
+		},
+		"failed": {
+			patterns:             NewPatterns("build", "lint"),
+			checks:               []Check{completed("build", ConclusionCancelled), inProgress("lint", testStart)},
+			expectedOutcome:      OutcomeFailed,
+			expectedExplanations: []string{`"build" concluded cancelled`, `"lint" in_progress`},
+		},
+		"timed out": {
+			patterns:             []Pattern{{Pattern: "slow", Timeout: time.Minute}},
+			checks:               []Check{inProgress("slow", testStart.Add(-time.Hour))},
+			expectedOutcome:      OutcomeTimedOut,
+			expectedExplanations: []string{`"slow" in_progress longer than timeout 1m0s`},
+		},
+		"waiting on conclusion": {
+			patterns:             []Pattern{{Pattern: "deploy", ConclusionPolicy: ConclusionPolicy{ConclusionActionRequired: VerdictWait}}},
+			checks:               []Check{completed("deploy", ConclusionActionRequired)},
+			expectedOutcome:      OutcomeWaiting,
+			expectedExplanations: []string{`"deploy" concluded action_required, waiting for a re-run`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rules, err := NewRuleset(tc.patterns)
+			require.NoError(t, err)
+
+			d := Evaluate(Snapshot{Checks: tc.checks, Now: testStart}, rules, env)
+
+			assert.Equal(t, tc.expectedOutcome, d.Outcome)
+			assert.Equal(t, tc.expectedExplanations, lo.Map(d.Patterns, func(item PatternState, _ int) string { return item.Explanation }))
+		})
+	}
+}
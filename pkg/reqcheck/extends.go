@@ -0,0 +1,261 @@
+package reqcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// maxExtendsDepth is the maximum number of config files that can be extended in a chain.
+const maxExtendsDepth = 5
+
+// ExtendsRef references a config file in another repository, written as owner/repo/path@ref. The ref is optional and
+// defaults to the repository's default branch.
+type ExtendsRef struct {
+	Owner string
+	Repo  string
+	Path  string
+	Ref   string
+}
+
+// ParseExtendsRef parses an owner/repo/path@ref reference.
+func ParseExtendsRef(s string) (ExtendsRef, error) {
+	var r ExtendsRef
+	location, ref, _ := strings.Cut(s, "@")
+	r.Ref = ref
+	parts := strings.SplitN(location, "/", 3)
+	if len(parts) != 3 || lo.Contains(parts, "") {
+		return ExtendsRef{}, fmt.Errorf("invalid extends %q, must be owner/repo/path@ref", s)
+	}
+	r.Owner, r.Repo, r.Path = parts[0], parts[1], parts[2]
+	return r, nil
+}
+
+func (r ExtendsRef) String() string {
+	s := fmt.Sprintf("%s/%s/%s", r.Owner, r.Repo, r.Path)
+	if r.Ref != "" {
+		s += "@" + r.Ref
+	}
+	return s
+}
+
+// LoadExtends fetches the config file referenced by extends, and any config files that it extends in turn, and
+// returns the config they define.
+func LoadExtends(ctx context.Context, reporter Reporter, contents ContentsClient, extends string) (*Config, error) {
+	return loadExtends(ctx, reporter, contents, extends, nil)
+}
+
+func loadExtends(ctx context.Context, reporter Reporter, contents ContentsClient, extends string, seen []string) (*Config, error) {
+	if slices.Contains(seen, extends) {
+		return nil, fmt.Errorf("extends cycle: %s", strings.Join(append(seen, extends), " -> "))
+	}
+	if len(seen) >= maxExtendsDepth {
+		return nil, fmt.Errorf("extends more than %d config files: %s", maxExtendsDepth, strings.Join(append(seen, extends), " -> "))
+	}
+	if contents == nil {
+		return nil, errors.New("extends requires the GitHub API")
+	}
+
+	ref, err := ParseExtendsRef(extends)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter.Infof("Extending config file %s", ref)
+	b, err := contents.GetContents(ctx, ref.Owner, ref.Repo, ref.Path, ref.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("extends %s: %w", ref, err)
+	}
+	file, err := ParseConfigFile(b)
+	if err != nil {
+		return nil, fmt.Errorf("extends %s: %w", ref, err)
+	}
+
+	c := DefaultConfig()
+	if err := file.Apply(c); err != nil {
+		return nil, fmt.Errorf("extends %s: %w", ref, err)
+	}
+	if file.Extends != "" {
+		parent, err := loadExtends(ctx, reporter, contents, file.Extends, append(seen, extends))
+		if err != nil {
+			return nil, err
+		}
+		MergeInherited(c, parent, reporter)
+	}
+	return c, nil
+}
+
+// MergeInherited merges the inherited config into c. The config can add patterns, and make inherited patterns
+// stricter, but cannot remove or relax them. Attempts to relax inherited patterns are ignored with a warning.
+func MergeInherited(c, inherited *Config, reporter Reporter) {
+	c.RequiredWorkflowPatterns = mergePatterns(inherited.RequiredWorkflowPatterns, c.RequiredWorkflowPatterns, inherited.ConclusionPolicy, reporter)
+
+	if c.ConditionalPathWorkflowPatterns == nil {
+		c.ConditionalPathWorkflowPatterns = map[string]PathRule{}
+	}
+	for key, rule := range inherited.ConditionalPathWorkflowPatterns {
+		local, ok := c.ConditionalPathWorkflowPatterns[key]
+		// Changing the paths could exclude files that the inherited rule requires checks for.
+		if ok && !slices.Equal(local.Paths, rule.Paths) {
+			reporter.Warningf("Cannot change the paths of inherited path rule %q: ignoring %q", key, local.Paths)
+		}
+		if ok && !slices.Equal(local.Status, rule.Status) {
+			reporter.Warningf("Cannot change the status of inherited path rule %q: ignoring %q", key, local.Status)
+		}
+		rule.Patterns = mergePatterns(rule.Patterns, local.Patterns, inherited.ConclusionPolicy, reporter)
+		c.ConditionalPathWorkflowPatterns[key] = rule
+	}
+
+	if c.CheckApps == nil {
+		c.CheckApps = map[string][]string{}
+	}
+	for pattern, apps := range inherited.CheckApps {
+		c.CheckApps[pattern] = restrictApps(pattern, apps, c.CheckApps[pattern], reporter)
+	}
+	// The check_apps of a pattern are added to its apps, so they cannot allow apps the inherited pattern does not.
+	inheritedPatterns := slices.Clone(inherited.RequiredWorkflowPatterns)
+	for _, rule := range inherited.ConditionalPathWorkflowPatterns {
+		inheritedPatterns = append(inheritedPatterns, rule.Patterns...)
+	}
+	for _, p := range inheritedPatterns {
+		if apps, ok := c.CheckApps[p.Pattern]; ok && len(p.App) > 0 {
+			c.CheckApps[p.Pattern] = restrictApps(p.Pattern, p.App, apps, reporter)
+		}
+	}
+
+	c.ConclusionPolicy = stricterPolicy("", DefaultConclusionPolicy.Merge(inherited.ConclusionPolicy), inherited.ConclusionPolicy, c.ConclusionPolicy, reporter)
+
+	if inherited.Timeout > 0 && (c.Timeout == 0 || c.Timeout > inherited.Timeout) {
+		if c.Timeout > 0 {
+			reporter.Warningf("Cannot relax inherited timeout: ignoring %s", c.Timeout)
+		}
+		c.Timeout = inherited.Timeout
+	}
+}
+
+// mergePatterns keeps every inherited pattern, merged with the local pattern of the same name, followed by the local
+// patterns that are not inherited.
+func mergePatterns(inherited, local []Pattern, policy ConclusionPolicy, reporter Reporter) []Pattern {
+	localByName := lo.KeyBy(local, func(item Pattern) string { return item.Pattern })
+	merged := make([]Pattern, 0, len(inherited)+len(local))
+	for _, p := range inherited {
+		if q, ok := localByName[p.Pattern]; ok {
+			p = mergePattern(p, q, policy, reporter)
+		}
+		merged = append(merged, p)
+	}
+
+	inheritedNames := lo.SliceToMap(inherited, func(item Pattern) (string, bool) { return item.Pattern, true })
+	for _, q := range local {
+		if !inheritedNames[q.Pattern] {
+			merged = append(merged, q)
+		}
+	}
+	return merged
+}
+
+// mergePattern applies the local pattern's policy to the inherited pattern where it is at least as strict.
+func mergePattern(p, q Pattern, policy ConclusionPolicy, reporter Reporter) Pattern {
+	relaxed := func(format string, args ...any) {
+		reporter.Warningf("Cannot relax inherited pattern %q: ignoring %s", p.Pattern, fmt.Sprintf(format, args...))
+	}
+
+	m := p
+	if q.Optional && !p.Optional {
+		relaxed("optional")
+	}
+	m.Optional = p.Optional && q.Optional
+
+	if q.MinCount > 0 && q.MinCount < p.MinCount {
+		relaxed("min_count %d", q.MinCount)
+	}
+	m.MinCount = max(p.MinCount, q.MinCount)
+
+	switch {
+	case p.Timeout == 0:
+		m.Timeout = q.Timeout
+	case q.Timeout > p.Timeout:
+		relaxed("timeout %s", q.Timeout)
+	case q.Timeout > 0:
+		m.Timeout = q.Timeout
+	}
+
+	m.App = restrictApps(p.Pattern, p.App, q.App, reporter)
+
+	effective := DefaultConclusionPolicy.Merge(policy).Merge(p.ConclusionPolicy)
+	m.ConclusionPolicy = stricterPolicy(p.Pattern, effective, p.ConclusionPolicy, q.ConclusionPolicy, reporter)
+
+	switch {
+	case len(q.AllowedConclusions) == 0:
+	case len(p.AllowedConclusions) > 0:
+		allowed := lo.Intersect(p.AllowedConclusions, q.AllowedConclusions)
+		if extra := lo.Without(q.AllowedConclusions, p.AllowedConclusions...); len(extra) > 0 {
+			relaxed("allowed_conclusions %q", extra)
+		}
+		if len(allowed) > 0 {
+			m.AllowedConclusions = allowed
+		}
+	default:
+		// Only allow conclusions that the inherited policy passes.
+		allowed := lo.Filter(q.AllowedConclusions, func(item string, _ int) bool { return effective.Verdict(item) == VerdictPass })
+		if extra := lo.Without(q.AllowedConclusions, allowed...); len(extra) > 0 {
+			relaxed("allowed_conclusions %q", extra)
+		}
+		if len(allowed) > 0 {
+			m.AllowedConclusions = allowed
+		}
+	}
+
+	if m.Description == "" {
+		m.Description = q.Description
+	}
+	return m
+}
+
+// restrictApps returns the local apps if they are a subset of the inherited apps.
+func restrictApps(pattern string, inherited, local []string, reporter Reporter) StringList {
+	if len(inherited) == 0 {
+		return local
+	}
+	if len(local) == 0 {
+		return inherited
+	}
+	if extra := lo.Without(local, inherited...); len(extra) > 0 {
+		reporter.Warningf("Cannot relax inherited pattern %q: ignoring apps %q", pattern, extra)
+	}
+	if allowed := lo.Intersect(inherited, local); len(allowed) > 0 {
+		return allowed
+	}
+	return inherited
+}
+
+var verdictStrictness = map[Verdict]int{VerdictPass: 0, VerdictWait: 1, VerdictFail: 2}
+
+// stricterPolicy overrides the inherited policy with the local verdicts that are at least as strict as the effective
+// inherited verdict.
+func stricterPolicy(pattern string, effective, inherited, local ConclusionPolicy, reporter Reporter) ConclusionPolicy {
+	if len(local) == 0 {
+		return inherited
+	}
+	merged := maps.Clone(inherited)
+	if merged == nil {
+		merged = ConclusionPolicy{}
+	}
+	for conclusion, v := range local {
+		if verdictStrictness[v] < verdictStrictness[effective.Verdict(conclusion)] {
+			if pattern == "" {
+				reporter.Warningf("Cannot relax inherited conclusion policy: ignoring %s: %s", conclusion, v)
+			} else {
+				reporter.Warningf("Cannot relax inherited pattern %q: ignoring conclusion_policy %s: %s", pattern, conclusion, v)
+			}
+			continue
+		}
+		merged[conclusion] = v
+	}
+	return merged
+}
@@ -0,0 +1,187 @@
+package reqcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/roryq/required-checks/pkg/xassert"
+)
+
+func TestLoadExtends(t *testing.T) {
+	tests := map[string]struct {
+		Files       map[string]string
+		Extends     string
+		AssertError assert.ErrorAssertionFunc
+	}{
+		"Chain": {
+			Files: map[string]string{
+				"org/policy/team.yaml@v1": "extends: org/policy/base.yaml@v1\nrequired_workflow_patterns: [tests]",
+				"org/policy/base.yaml@v1": "required_workflow_patterns: [security-scan]",
+			},
+			Extends:     "org/policy/team.yaml@v1",
+			AssertError: assert.NoError,
+		},
+		"Cycle": {
+			Files: map[string]string{
+				"org/policy/a.yaml@v1": "extends: org/policy/b.yaml@v1",
+				"org/policy/b.yaml@v1": "extends: org/policy/a.yaml@v1",
+			},
+			Extends:     "org/policy/a.yaml@v1",
+			AssertError: xassert.ErrorContains("extends cycle: org/policy/a.yaml@v1 -> org/policy/b.yaml@v1 -> org/policy/a.yaml@v1"),
+		},
+		"NotFound": {
+			Extends:     "org/policy/missing.yaml@v1",
+			AssertError: xassert.ErrorContains("extends org/policy/missing.yaml@v1"),
+		},
+		"Invalid": {
+			Extends:     "org/policy",
+			AssertError: xassert.ErrorContains(`invalid extends "org/policy", must be owner/repo/path@ref`),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			action, _ := setupAction("pull-request.opened")
+
+			config, err := LoadExtends(context.Background(), action, &mockContentsClient{Files: tt.Files}, tt.Extends)
+			tt.AssertError(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, NewPatterns("security-scan", "tests"), config.RequiredWorkflowPatterns)
+		})
+	}
+}
+
+func TestMergeInherited(t *testing.T) {
+	tests := map[string]struct {
+		Inherited string
+		Local     string
+		Expected  []Pattern
+		CheckApps map[string][]string
+		Warnings  []string
+	}{
+		"AddsPatterns": {
+			Inherited: "required_workflow_patterns: [security-scan]",
+			Local:     "required_workflow_patterns: [tests]",
+			Expected:  NewPatterns("security-scan", "tests"),
+		},
+		"CannotRemovePatterns": {
+			Inherited: "required_workflow_patterns: [security-scan]",
+			Local:     "timeout: 10m",
+			Expected:  NewPatterns("security-scan"),
+		},
+		"CannotRelaxAppsWithCheckApps": {
+			Inherited: `
+required_workflow_patterns:
+  - pattern: tests
+    app: github-actions
+`,
+			Local: `
+check_apps:
+  tests: [evil-app]
+  lint: [github-actions]
+`,
+			Expected:  []Pattern{{Pattern: "tests", App: StringList{"github-actions"}}},
+			CheckApps: map[string][]string{"tests": {"github-actions"}, "lint": {"github-actions"}},
+			Warnings:  []string{`Cannot relax inherited pattern "tests": ignoring apps ["evil-app"]`},
+		},
+		"CannotChangePaths": {
+			Inherited: `
+conditional_path_workflow_patterns:
+  go:
+    paths: ["**/*.go"]
+    patterns: [go-tests]
+`,
+			Local: `
+conditional_path_workflow_patterns:
+  go:
+    paths: ["**/*.go", "!vendor/**"]
+    patterns: [go-tests]
+`,
+			Expected: []Pattern{},
+			Warnings: []string{`Cannot change the paths of inherited path rule "go": ignoring ["**/*.go" "!vendor/**"]`},
+		},
+		"Tightens": {
+			Inherited: `
+required_workflow_patterns:
+  - pattern: tests
+    optional: true
+    timeout: 30m
+`,
+			Local: `
+required_workflow_patterns:
+  - pattern: tests
+    min_count: 2
+    timeout: 10m
+    allowed_conclusions: [success]
+`,
+			Expected: []Pattern{{Pattern: "tests", MinCount: 2, Timeout: 10 * time.Minute, AllowedConclusions: []string{"success"}}},
+		},
+		"CannotRelax": {
+			Inherited: `
+required_workflow_patterns:
+  - pattern: tests
+    min_count: 2
+    timeout: 10m
+    app: github-actions
+    conclusion_policy: {skipped: fail}
+`,
+			Local: `
+required_workflow_patterns:
+  - pattern: tests
+    optional: true
+    min_count: 1
+    timeout: 1h
+    app: [github-actions, circleci-checks]
+    allowed_conclusions: [success, skipped, failure]
+    conclusion_policy: {skipped: pass, neutral: fail}
+`,
+			Expected: []Pattern{{
+				Pattern:            "tests",
+				MinCount:           2,
+				Timeout:            10 * time.Minute,
+				App:                StringList{"github-actions"},
+				AllowedConclusions: []string{"success"},
+				ConclusionPolicy:   ConclusionPolicy{"skipped": VerdictFail, "neutral": VerdictFail},
+			}},
+			Warnings: []string{
+				`Cannot relax inherited pattern "tests": ignoring optional`,
+				`Cannot relax inherited pattern "tests": ignoring min_count 1`,
+				`Cannot relax inherited pattern "tests": ignoring timeout 1h0m0s`,
+				`Cannot relax inherited pattern "tests": ignoring apps ["circleci-checks"]`,
+				`Cannot relax inherited pattern "tests": ignoring conclusion_policy skipped: pass`,
+				`Cannot relax inherited pattern "tests": ignoring allowed_conclusions ["skipped" "failure"]`,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			action, output := setupAction("pull-request.opened")
+			inherited, local := DefaultConfig(), DefaultConfig()
+			for _, f := range []struct {
+				c    *Config
+				yaml string
+			}{{inherited, tt.Inherited}, {local, tt.Local}} {
+				file, err := ParseConfigFile([]byte(f.yaml))
+				require.NoError(t, err)
+				require.NoError(t, file.Apply(f.c))
+			}
+
+			MergeInherited(local, inherited, action)
+
+			assert.Equal(t, tt.Expected, local.RequiredWorkflowPatterns)
+			if tt.CheckApps != nil {
+				assert.Equal(t, tt.CheckApps, local.CheckApps)
+			}
+			for _, w := range tt.Warnings {
+				assert.Contains(t, output.String(), "::warning::"+w)
+			}
+		})
+	}
+}
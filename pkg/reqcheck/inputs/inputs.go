@@ -4,20 +4,51 @@ const (
 	// Token required for the GitHub API
 	Token = "TOKEN"
 
+	// ConfigPath path to a yaml config file in the repository, read from the local checkout or the GitHub API.
+	ConfigPath = "CONFIG_PATH"
+
+	// ConfigSource where to read the config file from, head or base. Reading from the base stops a pull request
+	// weakening its own required checks.
+	ConfigSource = "CONFIG_SOURCE"
+
+	// ConfigDrift how to report differences between the head and base config files when reading from the base: ignore,
+	// warn or fail.
+	ConfigDrift = "CONFIG_DRIFT"
+
 	// RequiredWorkflowPatterns is a yaml list of patterns to check
 	RequiredWorkflowPatterns = "REQUIRED_WORKFLOW_PATTERNS"
 
 	// ConditionalPathWorkflowPatterns path globs and patterns defining optional workflows to check for certain file changes.
 	ConditionalPathWorkflowPatterns = "CONDITIONAL_PATH_WORKFLOW_PATTERNS"
 
+	// CheckApps regex patterns and the GitHub App slugs or IDs that are allowed to report checks matching them.
+	CheckApps = "CHECK_APPS"
+
+	// ConclusionPolicy yaml dictionary of check conclusions and whether they pass, fail or wait.
+	ConclusionPolicy = "CONCLUSION_POLICY"
+
 	// InitialDelaySeconds Initial delay before polling
 	InitialDelaySeconds = "INITIAL_DELAY_SECONDS"
 
 	// PollFrequencySeconds Polling frequency
 	PollFrequencySeconds = "POLL_FREQUENCY_SECONDS"
 
+	// Timeout overall time to wait for required checks, as a duration e.g. 30m or a number of seconds.
+	Timeout = "TIMEOUT"
+
 	TargetSHA = "TARGET_SHA"
 
+	// CheckRunName name of a check run to publish the result to, with live progress while waiting. Not published if
+	// empty.
+	CheckRunName = "CHECK_RUN_NAME"
+
+	// PRComment whether to create or update a single pull request comment summarising the required checks.
+	PRComment = "PR_COMMENT"
+
+	// GitChangedFiles whether to list the changed files from the local git checkout, using the merge base of the base
+	// and head commits, instead of the GitHub API which lists at most 3000 files.
+	GitChangedFiles = "GIT_CHANGED_FILES"
+
 	// MissingRequiredRetryCount is the number of times to retry if a required check is missing, for cases where the workflow is still being created.
 	MissingRequiredRetryCount = "MISSING_REQUIRED_RETRY_COUNT"
 
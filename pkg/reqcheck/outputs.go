@@ -0,0 +1,129 @@
+package reqcheck
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/samber/lo"
+
+	"github.com/roryq/required-checks/pkg/reqcheck/outputs"
+)
+
+// Results of waiting for the required checks, set as the result output.
+const (
+	ResultPassed  = "passed"
+	ResultFailed  = "failed"
+	ResultMissing = "missing"
+	ResultTimeout = "timeout"
+	ResultError   = "error"
+)
+
+// evaluation is the JSON document of the evaluation output.
+type evaluation struct {
+	Result          string           `json:"result"`
+	Error           string           `json:"error,omitempty"`
+	Checks          []evaluatedCheck `json:"checks"`
+	FailedChecks    []string         `json:"failed_checks"`
+	MissingPatterns []string         `json:"missing_patterns"`
+	Paths           []evaluatedPath  `json:"conditional_paths,omitempty"`
+}
+
+type evaluatedCheck struct {
+	Name       string  `json:"name"`
+	Pattern    string  `json:"pattern"`
+	Source     string  `json:"source"`
+	App        string  `json:"app,omitempty"`
+	Status     string  `json:"status"`
+	Conclusion string  `json:"conclusion,omitempty"`
+	Verdict    Verdict `json:"verdict"`
+	DetailsURL string  `json:"details_url,omitempty"`
+}
+
+type evaluatedPath struct {
+	Glob       string   `json:"glob"`
+	Paths      []string `json:"paths,omitempty"`
+	Status     []string `json:"status,omitempty"`
+	File       string   `json:"matched_file,omitempty"`
+	FileStatus string   `json:"matched_file_status,omitempty"`
+	Files      []string `json:"matched_files,omitempty"`
+	Patterns   []string `json:"patterns"`
+}
+
+// setOutputs sets the outputs of the last poll, if the reporter supports outputs.
+func (s *summary) setOutputs(reporter Reporter, err error) {
+	if _, ok := reporter.(OutputReporter); !ok {
+		return
+	}
+
+	e := s.evaluation(err)
+	setOutput(reporter, outputs.Result, e.Result)
+	setOutput(reporter, outputs.FailedChecks, toJSON(e.FailedChecks))
+	setOutput(reporter, outputs.MissingPatterns, toJSON(e.MissingPatterns))
+	setOutput(reporter, outputs.MatchedChecks, toJSON(lo.Map(e.Checks, func(item evaluatedCheck, _ int) string { return item.Name })))
+	setOutput(reporter, outputs.Evaluation, toJSON(e))
+}
+
+func (s *summary) evaluation(err error) evaluation {
+	d := s.decision
+	e := evaluation{
+		Result: ResultPassed,
+		Checks: lo.Map(d.Matches, func(item Match, _ int) evaluatedCheck {
+			verdict := VerdictWait
+			if item.GetStatus() == StatusCompleted {
+				verdict = item.Rule.Verdict(item.Check)
+			}
+			return evaluatedCheck{
+				Name:       item.GetName(),
+				Pattern:    item.Rule.String(),
+				Source:     item.Source,
+				App:        checkApp(item.Check),
+				Status:     item.GetStatus(),
+				Conclusion: item.GetConclusion(),
+				Verdict:    verdict,
+				DetailsURL: item.GetDetailsURL(),
+			}
+		}),
+		FailedChecks:    matchNames(d.Failed),
+		MissingPatterns: sortStrings(ruleNames(d.Missing)),
+		Paths: lo.Map(s.paths, func(item PathMatch, _ int) evaluatedPath {
+			return evaluatedPath{
+				Glob:       item.Glob,
+				Paths:      item.Paths,
+				Status:     item.Status,
+				File:       item.File,
+				FileStatus: item.FileStatus,
+				Files:      lo.Map(item.Files, func(f ChangedFile, _ int) string { return f.Filename }),
+				Patterns:   PatternNames(item.Patterns),
+			}
+		}),
+	}
+	if err == nil {
+		return e
+	}
+
+	e.Error = err.Error()
+	var (
+		timeoutErr *TimeoutError
+		missingErr *MissingChecksError
+		failedErr  *FailedChecksError
+	)
+	switch {
+	case errors.As(err, &timeoutErr):
+		e.Result = ResultTimeout
+	case errors.As(err, &missingErr):
+		e.Result = ResultMissing
+	case errors.As(err, &failedErr):
+		e.Result = ResultFailed
+	default:
+		e.Result = ResultError
+	}
+	return e
+}
+
+func toJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,18 @@
+package outputs
+
+const (
+	// Result of waiting for the required checks: passed, failed, missing, timeout or error.
+	Result = "result"
+
+	// FailedChecks JSON list of the names of the checks that failed.
+	FailedChecks = "failed_checks"
+
+	// MissingPatterns JSON list of the required patterns that did not match any checks.
+	MissingPatterns = "missing_patterns"
+
+	// MatchedChecks JSON list of the names of the checks that matched a required pattern.
+	MatchedChecks = "matched_checks"
+
+	// Evaluation JSON document of the result, every matched check, and the conditional path globs.
+	Evaluation = "evaluation"
+)
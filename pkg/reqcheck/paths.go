@@ -0,0 +1,138 @@
+package reqcheck
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// PathRule requires its patterns when a changed file matches its paths. In yaml a rule is either a list of patterns,
+// keyed by its glob, or a mapping with paths and patterns, e.g.
+//
+//	"**/*.sql": [ validate-migrations ]
+//	go:
+//	  paths: [ "**/*.go", "!**/mocks/**", "!**/testdata/**" ]
+//	  patterns: [ go-unit-tests ]
+//	"db/migrations/**":
+//	  status: [ removed, renamed ]
+//	  patterns: [ migration-review ]
+type PathRule struct {
+	// Paths are the doublestar globs of the files that require the patterns, in order. Globs prefixed with ! exclude
+	// the files matched by earlier globs, and as in .gitignore the last glob that matches a file decides whether it is
+	// included. If empty, the key of the rule is its only glob. Renamed files match by their new or previous name.
+	Paths []string `yaml:"paths,omitempty"`
+	// Status restricts the rule to files with one of the statuses, e.g. added, modified, removed or renamed.
+	Status   StringList `yaml:"status,omitempty"`
+	Patterns []Pattern  `yaml:"patterns"`
+}
+
+func (r *PathRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		return node.Decode(&r.Patterns)
+	}
+	type plain PathRule
+	return node.Decode((*plain)(r))
+}
+
+// MarshalYAML writes rules without paths as a plain list of patterns.
+func (r PathRule) MarshalYAML() (any, error) {
+	if len(r.Paths) == 0 && len(r.Status) == 0 {
+		return r.Patterns, nil
+	}
+	type plain PathRule
+	return plain(r), nil
+}
+
+// globs returns the paths of the rule, or the key if it has none.
+func (r PathRule) globs(key string) []string {
+	if len(r.Paths) == 0 {
+		return []string{key}
+	}
+	return r.Paths
+}
+
+// match returns the name or previous name of the file that is matched by the globs of the rule, if the file has one
+// of the rule's statuses.
+func (r PathRule) match(key string, file ChangedFile) (string, bool) {
+	if len(r.Status) > 0 && !slices.Contains(r.Status, file.Status) {
+		return "", false
+	}
+	return lo.Find(file.Names(), func(name string) bool { return matchGlobs(r.globs(key), name) })
+}
+
+// PathMatch is a conditional path rule, and the changed files that matched it if any.
+type PathMatch struct {
+	// Glob is the key of the rule, which is its glob unless the rule lists its paths.
+	Glob   string
+	Paths  []string
+	Status []string
+	// File is the name of the first matched file, which is its previous name if only that matched, and FileStatus how
+	// it changed.
+	File       string
+	FileStatus string
+	// Files are all the matched files, named as for File.
+	Files    []ChangedFile
+	Patterns []Pattern
+}
+
+// Matched reports whether a changed file matched the rule.
+func (m PathMatch) Matched() bool {
+	return m.File != ""
+}
+
+// String is the key of the rule, followed by its paths and statuses if it lists them.
+func (m PathMatch) String() string {
+	var details []string
+	if len(m.Paths) > 0 {
+		details = append(details, strings.Join(m.Paths, ", "))
+	}
+	if len(m.Status) > 0 {
+		details = append(details, "status "+strings.Join(m.Status, ", "))
+	}
+	if len(details) == 0 {
+		return m.Glob
+	}
+	return m.Glob + " (" + strings.Join(details, "; ") + ")"
+}
+
+// MatchPaths matches each conditional path rule, in sorted order, against the changed files.
+func MatchPaths(paths map[string]PathRule, files []ChangedFile) []PathMatch {
+	matches := make([]PathMatch, 0, len(paths))
+	for _, key := range sortedPathGlobs(paths) {
+		rule := paths[key]
+		match := PathMatch{Glob: key, Paths: rule.Paths, Status: rule.Status, Patterns: rule.Patterns}
+		for _, file := range files {
+			if name, ok := rule.match(key, file); ok {
+				match.Files = append(match.Files, ChangedFile{Filename: name, Status: file.Status})
+			}
+		}
+		if len(match.Files) > 0 {
+			match.File, match.FileStatus = match.Files[0].Filename, match.Files[0].Status
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// matchGlobs reports whether the last of the globs to match the file includes it rather than excludes it.
+func matchGlobs(globs []string, name string) bool {
+	included := false
+	for _, glob := range globs {
+		pattern, exclude := strings.CutPrefix(glob, "!")
+		if matched, _ := doublestar.Match(pattern, name); matched {
+			included = !exclude
+		}
+	}
+	return included
+}
+
+// sortedPathGlobs returns the keys of the conditional path rules in a stable order.
+func sortedPathGlobs(paths map[string]PathRule) []string {
+	globs := lo.Keys(paths)
+	sort.Strings(globs)
+	return globs
+}
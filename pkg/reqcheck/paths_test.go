@@ -0,0 +1,87 @@
+package reqcheck
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPaths(t *testing.T) {
+	testCases := map[string]struct {
+		rule     PathRule
+		files    []string
+		expected string
+		// expectedFiles are all the matched files, the first of which is expected.
+		expectedFiles []ChangedFile
+	}{
+		"key is the glob": {
+			rule:          PathRule{Patterns: NewPatterns("go-tests")},
+			files:         []string{"README.md", "pkg/main.go"},
+			expected:      "pkg/main.go",
+			expectedFiles: []ChangedFile{{Filename: "pkg/main.go"}},
+		},
+		"excludes files": {
+			rule:  PathRule{Paths: []string{"**/*.go", "!**/mocks/**", "!**/testdata/**"}, Patterns: NewPatterns("go-tests")},
+			files: []string{"pkg/mocks/client.go", "pkg/testdata/main.go"},
+		},
+		"matches file not excluded": {
+			rule:          PathRule{Paths: []string{"**/*.go", "!**/mocks/**"}, Patterns: NewPatterns("go-tests")},
+			files:         []string{"pkg/mocks/client.go", "pkg/client.go"},
+			expected:      "pkg/client.go",
+			expectedFiles: []ChangedFile{{Filename: "pkg/client.go"}},
+		},
+		"matches every file": {
+			rule:     PathRule{Patterns: NewPatterns("go-tests")},
+			files:    []string{"pkg/main.go", "README.md", "D\tpkg/old.go"},
+			expected: "pkg/main.go",
+			expectedFiles: []ChangedFile{
+				{Filename: "pkg/main.go"},
+				{Filename: "pkg/old.go", Status: FileRemoved},
+			},
+		},
+		"last match wins": {
+			rule:          PathRule{Paths: []string{"**/*.go", "!**/mocks/**", "**/mocks/keep.go"}, Patterns: NewPatterns("go-tests")},
+			files:         []string{"pkg/mocks/client.go", "pkg/mocks/keep.go"},
+			expected:      "pkg/mocks/keep.go",
+			expectedFiles: []ChangedFile{{Filename: "pkg/mocks/keep.go"}},
+		},
+		"exclude only": {
+			rule:  PathRule{Paths: []string{"!**/mocks/**"}, Patterns: NewPatterns("go-tests")},
+			files: []string{"pkg/client.go"},
+		},
+		"filters by status": {
+			rule:          PathRule{Status: StringList{FileRemoved}, Patterns: NewPatterns("go-tests")},
+			files:         []string{"A\tpkg/new.go", "M\tpkg/main.go", "D\tpkg/old.go"},
+			expected:      "pkg/old.go",
+			expectedFiles: []ChangedFile{{Filename: "pkg/old.go", Status: FileRemoved}},
+		},
+		"status without match": {
+			rule:  PathRule{Status: StringList{FileRemoved}, Patterns: NewPatterns("go-tests")},
+			files: []string{"pkg/main.go", "A\tpkg/new.go"},
+		},
+		"matches previous name of renamed file": {
+			rule:          PathRule{Paths: []string{"internal/**"}, Patterns: NewPatterns("go-tests")},
+			files:         []string{"R100\tinternal/auth.go\tpkg/auth.go"},
+			expected:      "internal/auth.go",
+			expectedFiles: []ChangedFile{{Filename: "internal/auth.go", Status: FileRenamed}},
+		},
+		"ignores previous name of copied file": {
+			rule:  PathRule{Paths: []string{"internal/**"}, Patterns: NewPatterns("go-tests")},
+			files: []string{"C100\tinternal/auth.go\tpkg/auth.go"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			files := lo.Map(tc.files, func(item string, _ int) ChangedFile { return ParseChangedFile(item) })
+
+			matches := MatchPaths(map[string]PathRule{"**/*.go": tc.rule}, files)
+
+			require.Len(t, matches, 1)
+			assert.Equal(t, tc.expected, matches[0].File)
+			assert.Equal(t, tc.expectedFiles, matches[0].Files)
+		})
+	}
+}
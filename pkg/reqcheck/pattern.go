@@ -0,0 +1,137 @@
+package reqcheck
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pattern is a required check pattern and its policy. In yaml it is either a plain regex string or a mapping:
+//
+//	pattern: unit-tests
+//	allowed_conclusions: [success]
+//	timeout: 30m
+//	min_count: 3
+//	app: github-actions
+type Pattern struct {
+	// Pattern is the regex to match check names, optionally prefixed with "check:" or "status:".
+	Pattern string `yaml:"pattern"`
+	// AllowedConclusions are the only conclusions that pass, all other conclusions fail.
+	AllowedConclusions []string `yaml:"allowed_conclusions,omitempty"`
+	// ConclusionPolicy overrides the global conclusion policy for matching checks.
+	ConclusionPolicy ConclusionPolicy `yaml:"conclusion_policy,omitempty"`
+	// Optional checks do not have to report, but must pass if they do.
+	Optional bool `yaml:"optional,omitempty"`
+	// Timeout is how long a matching check can run before failing.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// MinCount is the minimum number of checks that must match, e.g. for matrix jobs. Defaults to 1.
+	MinCount int `yaml:"min_count,omitempty"`
+	// App is the GitHub App slugs or IDs allowed to report matching checks.
+	App StringList `yaml:"app,omitempty"`
+	// Description explains why the check is required.
+	Description string `yaml:"description,omitempty"`
+}
+
+// NewPatterns creates patterns with the default policy.
+func NewPatterns(patterns ...string) []Pattern {
+	ps := make([]Pattern, 0, len(patterns))
+	for _, p := range patterns {
+		ps = append(ps, Pattern{Pattern: p})
+	}
+	return ps
+}
+
+func (p *Pattern) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		p.Pattern = node.Value
+		return nil
+	}
+
+	// The timeout is a duration or a number of seconds, as for the timeout input, which time.Duration does not decode.
+	var timeout *yaml.Node
+	stripped := *node
+	stripped.Content = nil
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "timeout" {
+			timeout = node.Content[i+1]
+			continue
+		}
+		stripped.Content = append(stripped.Content, node.Content[i], node.Content[i+1])
+	}
+
+	type plain Pattern
+	if err := stripped.Decode((*plain)(p)); err != nil {
+		return err
+	}
+	if timeout != nil {
+		d, err := parseDuration(timeout.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid timeout %q", timeout.Line, timeout.Value)
+		}
+		p.Timeout = d
+	}
+	if p.Pattern == "" {
+		return fmt.Errorf("line %d: pattern is required", node.Line)
+	}
+	return nil
+}
+
+// MarshalYAML writes patterns with the default policy as a plain string.
+func (p Pattern) MarshalYAML() (any, error) {
+	if p.isPlain() {
+		return p.Pattern, nil
+	}
+	type plain Pattern
+	return plain(p), nil
+}
+
+func (p Pattern) isPlain() bool {
+	return p.Pattern != "" &&
+		len(p.AllowedConclusions) == 0 &&
+		len(p.ConclusionPolicy) == 0 &&
+		!p.Optional &&
+		p.Timeout == 0 &&
+		p.MinCount == 0 &&
+		len(p.App) == 0 &&
+		p.Description == ""
+}
+
+// StringList is a list of strings that can also be written in yaml as a single string.
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(l))
+}
+
+// UniquePatterns de-duplicates the patterns by regex, merging the duplicates so that the strictest options of each
+// apply, e.g. when a conditional path rule requires a required pattern with a higher min_count.
+func UniquePatterns(patterns []Pattern, policy ConclusionPolicy) []Pattern {
+	var unique []Pattern
+	index := map[string]int{}
+	for _, p := range patterns {
+		i, ok := index[p.Pattern]
+		if !ok {
+			index[p.Pattern] = len(unique)
+			unique = append(unique, p)
+			continue
+		}
+		// Neither pattern is inherited, so there is no relaxed option to warn about.
+		unique[i] = mergePattern(unique[i], p, policy, LogReporter{W: io.Discard})
+	}
+	return unique
+}
+
+// PatternNames returns the regex of each pattern.
+func PatternNames(patterns []Pattern) []string {
+	names := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		names = append(names, p.Pattern)
+	}
+	return names
+}
@@ -0,0 +1,103 @@
+package reqcheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/roryq/required-checks/pkg/xassert"
+)
+
+func TestUniquePatterns(t *testing.T) {
+	testCases := map[string]struct {
+		patterns []Pattern
+		expected []Pattern
+	}{
+		"keeps unique patterns": {
+			patterns: NewPatterns("tests", "lint"),
+			expected: NewPatterns("tests", "lint"),
+		},
+		"merges stricter duplicate": {
+			patterns: []Pattern{
+				{Pattern: "tests"},
+				{Pattern: "lint"},
+				{Pattern: "tests", MinCount: 3, Timeout: 10 * time.Minute, AllowedConclusions: []string{ConclusionSuccess}},
+			},
+			expected: []Pattern{
+				{Pattern: "tests", MinCount: 3, Timeout: 10 * time.Minute, AllowedConclusions: []string{ConclusionSuccess}},
+				{Pattern: "lint"},
+			},
+		},
+		"keeps stricter first pattern": {
+			patterns: []Pattern{
+				{Pattern: "tests", MinCount: 3, Timeout: 10 * time.Minute},
+				{Pattern: "tests", Optional: true, MinCount: 1, Timeout: time.Hour},
+			},
+			expected: []Pattern{
+				{Pattern: "tests", MinCount: 3, Timeout: 10 * time.Minute},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, UniquePatterns(tc.patterns, nil))
+		})
+	}
+}
+
+func TestPattern_UnmarshalYAML(t *testing.T) {
+	testCases := map[string]struct {
+		yaml        string
+		expected    Pattern
+		assertError assert.ErrorAssertionFunc
+	}{
+		"plain": {
+			yaml:        "tests",
+			expected:    Pattern{Pattern: "tests"},
+			assertError: assert.NoError,
+		},
+		"duration timeout": {
+			yaml:        "{pattern: tests, timeout: 30m, min_count: 2}",
+			expected:    Pattern{Pattern: "tests", Timeout: 30 * time.Minute, MinCount: 2},
+			assertError: assert.NoError,
+		},
+		"seconds timeout": {
+			yaml:        "{pattern: tests, timeout: 1800}",
+			expected:    Pattern{Pattern: "tests", Timeout: 30 * time.Minute},
+			assertError: assert.NoError,
+		},
+		"invalid timeout": {
+			yaml:        "{pattern: tests, timeout: soon}",
+			assertError: xassert.ErrorContains(`line 1: invalid timeout "soon"`),
+		},
+		"missing pattern": {
+			yaml:        "{timeout: 30m}",
+			assertError: xassert.ErrorContains("line 1: pattern is required"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var p Pattern
+			err := yaml.Unmarshal([]byte(tc.yaml), &p)
+
+			tc.assertError(t, err)
+			if err == nil {
+				assert.Equal(t, tc.expected, p)
+			}
+		})
+	}
+}
+
+func TestPattern_MarshalYAML(t *testing.T) {
+	b, err := yaml.Marshal(Pattern{Pattern: "tests", Timeout: 30 * time.Minute})
+	require.NoError(t, err)
+
+	var p Pattern
+	require.NoError(t, yaml.Unmarshal(b, &p))
+	assert.Equal(t, Pattern{Pattern: "tests", Timeout: 30 * time.Minute}, p)
+}
@@ -0,0 +1,81 @@
+package reqcheck
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Verdict is the outcome of a completed check: pass, fail, or wait for it to be re-run.
+type Verdict string
+
+const (
+	VerdictPass Verdict = "pass"
+	VerdictFail Verdict = "fail"
+	VerdictWait Verdict = "wait"
+)
+
+var verdicts = []Verdict{VerdictPass, VerdictFail, VerdictWait}
+
+func (v *Verdict) UnmarshalYAML(node *yaml.Node) error {
+	if !slices.Contains(verdicts, Verdict(node.Value)) {
+		return fmt.Errorf("line %d: invalid verdict %q, must be one of %q", node.Line, node.Value, verdicts)
+	}
+	*v = Verdict(node.Value)
+	return nil
+}
+
+var conclusions = []string{
+	ConclusionActionRequired,
+	ConclusionCancelled,
+	ConclusionFailure,
+	ConclusionNeutral,
+	ConclusionSuccess,
+	ConclusionSkipped,
+	ConclusionStale,
+	ConclusionTimedOut,
+}
+
+// ConclusionPolicy maps the conclusion of a completed check to a verdict.
+type ConclusionPolicy map[string]Verdict
+
+// DefaultConclusionPolicy fails checks that failed, were cancelled, or timed out and passes all other conclusions.
+var DefaultConclusionPolicy = ConclusionPolicy{
+	ConclusionFailure:   VerdictFail,
+	ConclusionCancelled: VerdictFail,
+	ConclusionTimedOut:  VerdictFail,
+}
+
+func (p *ConclusionPolicy) UnmarshalYAML(node *yaml.Node) error {
+	var m map[string]Verdict
+	if err := node.Decode(&m); err != nil {
+		return err
+	}
+	for conclusion := range m {
+		if !slices.Contains(conclusions, conclusion) {
+			return fmt.Errorf("line %d: invalid conclusion %q, must be one of %q", node.Line, conclusion, conclusions)
+		}
+	}
+	*p = m
+	return nil
+}
+
+// Verdict returns the verdict for the conclusion, passing conclusions that are not in the policy.
+func (p ConclusionPolicy) Verdict(conclusion string) Verdict {
+	if v, ok := p[conclusion]; ok {
+		return v
+	}
+	return VerdictPass
+}
+
+// Merge returns a copy of the policy overridden by other.
+func (p ConclusionPolicy) Merge(other ConclusionPolicy) ConclusionPolicy {
+	merged := maps.Clone(p)
+	if merged == nil {
+		merged = ConclusionPolicy{}
+	}
+	maps.Copy(merged, other)
+	return merged
+}
@@ -0,0 +1,96 @@
+package reqcheck
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sethvargo/go-githubactions"
+)
+
+// Reporter logs the progress of waiting for the checks. *githubactions.Action is a Reporter.
+type Reporter interface {
+	Debugf(msg string, args ...any)
+	Infof(msg string, args ...any)
+	Warningf(msg string, args ...any)
+}
+
+// SummaryReporter is a Reporter that can also write a markdown summary, such as the GitHub Actions job summary.
+type SummaryReporter interface {
+	Reporter
+	AddStepSummary(markdown string)
+}
+
+// OutputReporter is a Reporter that can also set outputs, such as the GitHub Actions step outputs.
+type OutputReporter interface {
+	Reporter
+	SetOutput(name, value string)
+}
+
+// AnnotationReporter is a Reporter that can also report an error about a single check, such as a GitHub Actions
+// error annotation.
+type AnnotationReporter interface {
+	Reporter
+	AddErrorAnnotation(title, message string)
+}
+
+// ActionReporter reports to the GitHub Actions log, annotating the errors about each check with its name.
+type ActionReporter struct {
+	*githubactions.Action
+}
+
+func (r ActionReporter) AddErrorAnnotation(title, message string) {
+	r.WithFieldsMap(map[string]string{"title": title}).Errorf("%s", message)
+}
+
+// LogReporter is a Reporter that writes plain log lines, for running outside of GitHub Actions.
+type LogReporter struct {
+	W     io.Writer
+	Debug bool
+}
+
+func (r LogReporter) Debugf(msg string, args ...any) {
+	if r.Debug {
+		r.printf("debug: ", msg, args...)
+	}
+}
+
+func (r LogReporter) Infof(msg string, args ...any) {
+	r.printf("", msg, args...)
+}
+
+func (r LogReporter) Warningf(msg string, args ...any) {
+	r.printf("warning: ", msg, args...)
+}
+
+func (r LogReporter) AddErrorAnnotation(title, message string) {
+	r.printf("error: ", "%s: %s", title, message)
+}
+
+func (r LogReporter) printf(prefix, msg string, args ...any) {
+	fmt.Fprintln(r.W, prefix+strings.TrimSuffix(fmt.Sprintf(msg, args...), "\n"))
+}
+
+// addStepSummary writes the markdown if the reporter supports summaries.
+func addStepSummary(reporter Reporter, markdown string) {
+	if s, ok := reporter.(SummaryReporter); ok {
+		s.AddStepSummary(markdown)
+	}
+}
+
+// setOutput sets the output if the reporter supports outputs.
+func setOutput(reporter Reporter, name, value string) {
+	if o, ok := reporter.(OutputReporter); ok {
+		o.SetOutput(name, value)
+	}
+}
+
+// addErrorAnnotation reports the error about a check, falling back to a warning if the reporter does not support
+// annotations.
+func addErrorAnnotation(reporter Reporter, title, message string) {
+	if a, ok := reporter.(AnnotationReporter); ok {
+		a.AddErrorAnnotation(title, message)
+		return
+	}
+	reporter.Warningf("%s: %s", title, message)
+}
@@ -0,0 +1,142 @@
+package reqcheck
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// summary collects the state of the last poll, to write the step summary and outputs when waiting finishes.
+type summary struct {
+	// decision is the evaluation of the last poll.
+	decision Decision
+	paths    []PathMatch
+	// pathsSkipped is the reason the conditional path globs were not evaluated, if any.
+	pathsSkipped string
+	now          time.Time
+}
+
+// write adds the markdown summary and sets the outputs, if the reporter supports them.
+func (s *summary) write(reporter Reporter, err error) {
+	addStepSummary(reporter, s.markdown(err))
+	s.setOutputs(reporter, err)
+}
+
+func (s *summary) markdown(err error) string {
+	var b strings.Builder
+	b.WriteString("## Required checks\n\n")
+	if err != nil {
+		fmt.Fprintf(&b, ":x: %s\n\n", escapeMarkdown(err.Error()))
+	} else {
+		b.WriteString(":white_check_mark: All required checks passed\n\n")
+	}
+	b.WriteString(s.tables())
+	return b.String()
+}
+
+// tables is the markdown table of the checks matched by each rule, and of the conditional path globs.
+func (s *summary) tables() string {
+	var b strings.Builder
+	if len(s.decision.Patterns) > 0 {
+		b.WriteString("| Pattern | Check | Status | Conclusion | Duration | Details |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, p := range s.decision.Patterns {
+			r, matches := p.Rule, p.Matches
+			if len(matches) == 0 {
+				status := ":grey_question: not found"
+				if r.Optional {
+					status = "optional, not found"
+				}
+				fmt.Fprintf(&b, "| `%s` | | %s | | | |\n", escapeMarkdown(r.String()), status)
+				continue
+			}
+			for _, m := range matches {
+				fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n",
+					escapeMarkdown(r.String()),
+					escapeMarkdown(m.GetName()),
+					matchStatus(m),
+					m.GetConclusion(),
+					s.duration(m.Check),
+					detailsLink(m.Check),
+				)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(s.decision.Suspicious) > 0 {
+		b.WriteString("### Suspicious checks\n\n")
+		b.WriteString("Ignored checks that match a pattern, but were reported by an app the pattern does not allow.\n\n")
+		b.WriteString("| Check | App | Pattern | Allowed apps |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, m := range s.decision.Suspicious {
+			fmt.Fprintf(&b, "| %s | `%s` | `%s` | `%s` |\n",
+				escapeMarkdown(m.GetName()),
+				escapeMarkdown(checkApp(m.Check)),
+				escapeMarkdown(m.Rule.String()),
+				escapeMarkdown(strings.Join(m.Rule.Apps, "`, `")),
+			)
+		}
+		b.WriteString("\n")
+	}
+
+	switch {
+	case s.pathsSkipped != "":
+		fmt.Fprintf(&b, "### Conditional paths\n\n%s\n", s.pathsSkipped)
+	case len(s.paths) > 0:
+		b.WriteString("### Conditional paths\n\n")
+		b.WriteString("| Path glob | Result |\n")
+		b.WriteString("| --- | --- |\n")
+		for _, p := range s.paths {
+			result := "No changed files matched"
+			if p.Matched() {
+				file := fmt.Sprintf("`%s`", escapeMarkdown(p.File))
+				if p.FileStatus != "" {
+					file += " (" + p.FileStatus + ")"
+				}
+				result = fmt.Sprintf("Matched %s, requiring `%s`", file, escapeMarkdown(strings.Join(PatternNames(p.Patterns), "`, `")))
+			}
+			fmt.Fprintf(&b, "| `%s` | %s |\n", escapeMarkdown(p.String()), result)
+		}
+	}
+	return b.String()
+}
+
+// matchStatus is the status of the check, and whether it passed, failed or is waiting.
+func matchStatus(m Match) string {
+	switch {
+	case m.Rule.Failed(m.Check):
+		return ":x: " + m.GetStatus()
+	case m.Rule.Waiting(m.Check):
+		return ":hourglass: " + m.GetStatus()
+	default:
+		return ":white_check_mark: " + m.GetStatus()
+	}
+}
+
+// duration is how long the check ran for, or has been running for if it has not completed.
+func (s *summary) duration(c Check) string {
+	if c.StartedAt == nil {
+		return ""
+	}
+	end := s.now
+	if c.CompletedAt != nil {
+		end = c.CompletedAt.Time
+	}
+	if end.IsZero() || end.Before(c.StartedAt.Time) {
+		return ""
+	}
+	return end.Sub(c.StartedAt.Time).Round(time.Second).String()
+}
+
+func detailsLink(c Check) string {
+	if c.GetDetailsURL() == "" {
+		return ""
+	}
+	return fmt.Sprintf("[details](%s)", c.GetDetailsURL())
+}
+
+// escapeMarkdown escapes the characters that would break a markdown table cell.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,331 @@
+package reqcheck
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is a problem with the config, at the line and column of the yaml node if known.
+type ValidationError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ValidationErrors are all the problems found with the config.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// err returns the errors, or nil if there are none.
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+var (
+	configFileKeys = yamlKeys(reflect.TypeOf(ConfigFile{}))
+	patternKeys    = yamlKeys(reflect.TypeOf(Pattern{}))
+	pathRuleKeys   = yamlKeys(reflect.TypeOf(PathRule{}))
+)
+
+// ValidateConfigFile checks the config file for unknown keys, invalid regexes and path globs, empty pattern lists
+// and duplicate patterns, as well as values that cannot be decoded.
+func ValidateConfigFile(b []byte) ValidationErrors {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return ValidationErrors{{Message: err.Error()}}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return ValidationErrors{nodeError(root, "config file must be a mapping")}
+	}
+
+	var v validator
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "extends":
+			if _, err := ParseExtendsRef(value.Value); err != nil {
+				v.add(value, err.Error())
+			}
+		case "required_workflow_patterns":
+			v.patterns(value, key.Value)
+		case "conditional_path_workflow_patterns":
+			v.pathPatterns(value)
+		case "check_apps":
+			v.checkApps(value)
+		case "timeout":
+			if _, err := parseDuration(value.Value); err != nil {
+				v.add(value, fmt.Sprintf("invalid timeout %q", value.Value))
+			}
+		default:
+			if !slices.Contains(configFileKeys, key.Value) {
+				v.add(key, fmt.Sprintf("unknown key %q", key.Value))
+			}
+		}
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+
+	// Check the values decode, e.g. conclusion policy verdicts.
+	if err := root.Decode(new(ConfigFile)); err != nil {
+		return ValidationErrors{{Message: err.Error()}}
+	}
+	return nil
+}
+
+// validateInput validates the yaml value of an input with the validate function.
+func validateInput(name, value string, validate func(v *validator, node *yaml.Node)) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil || len(doc.Content) == 0 {
+		// Decoding reports the error.
+		return nil
+	}
+	var v validator
+	validate(&v, doc.Content[0])
+	if err := v.errs.err(); err != nil {
+		return fmt.Errorf("input %s: %w", strings.ToLower(name), err)
+	}
+	return nil
+}
+
+// Validate checks the patterns compile and the path globs are valid, so that a config that is not read from a config
+// file fails before waiting.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	patterns := slices.Clone(c.RequiredWorkflowPatterns)
+	for _, key := range sortedPathGlobs(c.ConditionalPathWorkflowPatterns) {
+		rule := c.ConditionalPathWorkflowPatterns[key]
+		for _, glob := range rule.globs(key) {
+			if err := validateGlob(glob); err != nil {
+				errs = append(errs, ValidationError{Message: err.Error()})
+			}
+		}
+		for _, status := range rule.Status {
+			if !slices.Contains(fileStatuses, status) {
+				errs = append(errs, ValidationError{Message: fmt.Sprintf("invalid file status %q, must be one of %q", status, fileStatuses)})
+			}
+		}
+		patterns = append(patterns, rule.Patterns...)
+	}
+	for _, p := range patterns {
+		if err := validatePattern(p.Pattern); err != nil {
+			errs = append(errs, ValidationError{Message: err.Error()})
+		}
+	}
+	return errs.err()
+}
+
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(node *yaml.Node, msg string) {
+	v.errs = append(v.errs, nodeError(node, msg))
+}
+
+// patterns validates a list of patterns, each a regex or a mapping with a pattern key.
+func (v *validator) patterns(node *yaml.Node, field string) {
+	if node.Kind != yaml.SequenceNode {
+		v.add(node, fmt.Sprintf("%s must be a list of patterns", field))
+		return
+	}
+	if len(node.Content) == 0 {
+		v.add(node, fmt.Sprintf("%s is empty", field))
+		return
+	}
+
+	seen := map[string]*yaml.Node{}
+	for _, item := range node.Content {
+		patternNode := item
+		switch item.Kind {
+		case yaml.ScalarNode:
+		case yaml.MappingNode:
+			patternNode = nil
+			for i := 0; i+1 < len(item.Content); i += 2 {
+				key := item.Content[i]
+				switch value := item.Content[i+1]; {
+				case key.Value == "pattern":
+					patternNode = value
+				case key.Value == "timeout":
+					if _, err := parseDuration(value.Value); err != nil {
+						v.add(value, fmt.Sprintf("invalid timeout %q", value.Value))
+					}
+				case !slices.Contains(patternKeys, key.Value):
+					v.add(key, fmt.Sprintf("unknown pattern key %q", key.Value))
+				}
+			}
+			if patternNode == nil {
+				v.add(item, "pattern is required")
+				continue
+			}
+		default:
+			v.add(item, "pattern must be a regex or a mapping")
+			continue
+		}
+
+		if err := validatePattern(patternNode.Value); err != nil {
+			v.add(patternNode, err.Error())
+			continue
+		}
+		if first, ok := seen[patternNode.Value]; ok {
+			v.add(patternNode, fmt.Sprintf("duplicate pattern %q, first defined at line %d", patternNode.Value, first.Line))
+			continue
+		}
+		seen[patternNode.Value] = patternNode
+	}
+}
+
+// pathPatterns validates a mapping of path globs to lists of patterns, or of rule names to path rules.
+func (v *validator) pathPatterns(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		v.add(node, "conditional_path_workflow_patterns must be a mapping of path globs to patterns")
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind == yaml.MappingNode {
+			v.pathRule(key, value)
+			continue
+		}
+		v.glob(key, key.Value)
+		v.patterns(value, fmt.Sprintf("patterns for path glob %q", key.Value))
+	}
+}
+
+// pathRule validates a path rule mapping with paths and patterns. The key is the glob if there are no paths.
+func (v *validator) pathRule(key, node *yaml.Node) {
+	var paths, patterns *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch k := node.Content[i]; k.Value {
+		case "paths":
+			paths = node.Content[i+1]
+		case "status":
+			v.fileStatus(node.Content[i+1])
+		case "patterns":
+			patterns = node.Content[i+1]
+		default:
+			if !slices.Contains(pathRuleKeys, k.Value) {
+				v.add(k, fmt.Sprintf("unknown path rule key %q", k.Value))
+			}
+		}
+	}
+
+	switch {
+	case paths == nil:
+		v.glob(key, key.Value)
+	case paths.Kind != yaml.SequenceNode || len(paths.Content) == 0:
+		v.add(paths, fmt.Sprintf("paths for path rule %q must be a list of globs", key.Value))
+	default:
+		for _, item := range paths.Content {
+			v.glob(item, item.Value)
+		}
+	}
+
+	if patterns == nil {
+		v.add(node, fmt.Sprintf("patterns for path rule %q is required", key.Value))
+		return
+	}
+	v.patterns(patterns, fmt.Sprintf("patterns for path rule %q", key.Value))
+}
+
+// fileStatus validates a file status, or a list of file statuses.
+func (v *validator) fileStatus(node *yaml.Node) {
+	statuses := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		statuses = node.Content
+	}
+	for _, status := range statuses {
+		if !slices.Contains(fileStatuses, status.Value) {
+			v.add(status, fmt.Sprintf("invalid file status %q, must be one of %q", status.Value, fileStatuses))
+		}
+	}
+}
+
+// glob validates a path glob, which may be prefixed with ! to exclude the files it matches.
+func (v *validator) glob(node *yaml.Node, glob string) {
+	if err := validateGlob(glob); err != nil {
+		v.add(node, err.Error())
+	}
+}
+
+// checkApps validates a mapping of patterns to the apps allowed to report them.
+func (v *validator) checkApps(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		v.add(node, "check_apps must be a mapping of patterns to apps")
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if err := validatePattern(key.Value); err != nil {
+			v.add(key, err.Error())
+		}
+		if value.Kind == yaml.SequenceNode && len(value.Content) == 0 {
+			v.add(value, fmt.Sprintf("apps for pattern %q is empty", key.Value))
+		}
+	}
+}
+
+// validateGlob checks the path glob is a valid doublestar glob after its ! prefix, if any.
+func validateGlob(glob string) error {
+	pattern, _ := strings.CutPrefix(glob, "!")
+	if pattern == "" || !doublestar.ValidatePattern(pattern) {
+		return fmt.Errorf("invalid path glob %q", glob)
+	}
+	return nil
+}
+
+// validatePattern checks the pattern is not empty and is a valid regex after its source prefix.
+func validatePattern(pattern string) error {
+	if pattern == "" {
+		return errors.New("pattern is empty")
+	}
+	_, expr := parseSource(pattern)
+	if _, err := regexp.Compile(expr); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+func nodeError(node *yaml.Node, msg string) ValidationError {
+	return ValidationError{Line: node.Line, Column: node.Column, Message: msg}
+}
+
+// yamlKeys returns the yaml keys of the struct's fields.
+func yamlKeys(t reflect.Type) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); name != "" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
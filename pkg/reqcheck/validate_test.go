@@ -0,0 +1,147 @@
+package reqcheck
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	tests := map[string]struct {
+		Config   string
+		Expected []string
+	}{
+		"Valid": {
+			Config: `
+extends: my-org/policy/required-checks.yaml@main
+required_workflow_patterns:
+  - tests
+  - pattern: status:ci/.*
+    min_count: 2
+    timeout: 1800
+  - pattern: e2e
+    timeout: 45m
+conditional_path_workflow_patterns:
+  "**/*.go": [go-tests]
+  sql:
+    paths: ["**/*.sql", "!**/testdata/**"]
+    patterns: [validate-migrations]
+  "db/migrations/**":
+    status: [removed, renamed]
+    patterns: [migration-review]
+check_apps:
+  tests: [github-actions]
+timeout: 30m
+`,
+		},
+		"Empty": {},
+		"UnknownKeys": {
+			Config: `
+required_workflow_pattern:
+  - tests
+required_workflow_patterns:
+  - pattern: lint
+    allowed_conclusion: [success]
+`,
+			Expected: []string{
+				`line 2, column 1: unknown key "required_workflow_pattern"`,
+				`line 6, column 5: unknown pattern key "allowed_conclusion"`,
+			},
+		},
+		"InvalidPatternsAndGlobs": {
+			Config: `
+required_workflow_patterns: []
+conditional_path_workflow_patterns:
+  "src/[a-": [go-tests]
+  "**/*.sql":
+    - migrations
+    - migrations
+check_apps:
+  "tests(": [github-actions]
+timeout: soon
+`,
+			Expected: []string{
+				"line 2, column 29: required_workflow_patterns is empty",
+				`line 4, column 3: invalid path glob "src/[a-"`,
+				`line 7, column 7: duplicate pattern "migrations", first defined at line 6`,
+				"line 9, column 3: invalid pattern \"tests(\": error parsing regexp: missing closing ): `tests(`",
+				`line 10, column 10: invalid timeout "soon"`,
+			},
+		},
+		"InvalidPathRules": {
+			Config: `
+conditional_path_workflow_patterns:
+  go:
+    paths: ["**/*.go", "!"]
+    pattern: [go-tests]
+  "docs/[a-":
+    patterns: [docs]
+  sql:
+    paths: []
+    status: deleted
+    patterns: [validate-migrations]
+`,
+			Expected: []string{
+				`line 5, column 5: unknown path rule key "pattern"`,
+				`line 4, column 24: invalid path glob "!"`,
+				`line 4, column 5: patterns for path rule "go" is required`,
+				`line 6, column 3: invalid path glob "docs/[a-"`,
+				`line 10, column 13: invalid file status "deleted", must be one of ["added" "modified" "removed" "renamed" "copied" "changed"]`,
+				`line 9, column 12: paths for path rule "sql" must be a list of globs`,
+			},
+		},
+		"InvalidPatternTimeout": {
+			Config: `
+required_workflow_patterns:
+  - pattern: tests
+    timeout: soon
+`,
+			Expected: []string{`line 4, column 14: invalid timeout "soon"`},
+		},
+		"InvalidValue": {
+			Config:   "conclusion_policy:\n  stale: ignore",
+			Expected: []string{`line 2: invalid verdict "ignore"`},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs := ValidateConfigFile([]byte(tt.Config))
+			if len(tt.Expected) == 0 {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, len(tt.Expected))
+			for i, expected := range tt.Expected {
+				assert.Contains(t, errs[i].Error(), expected)
+			}
+		})
+	}
+}
+
+func TestConfigFileSchema(t *testing.T) {
+	b, err := os.ReadFile("../../schema/required-checks.schema.json")
+	require.NoError(t, err)
+	var schema struct {
+		Properties map[string]any `json:"properties"`
+		Defs       struct {
+			PathRule struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"pathRule"`
+			Pattern struct {
+				OneOf []struct {
+					Properties map[string]any `json:"properties"`
+				} `json:"oneOf"`
+			} `json:"pattern"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(b, &schema))
+
+	assert.ElementsMatch(t, configFileKeys, lo.Keys(schema.Properties))
+	assert.ElementsMatch(t, patternKeys, lo.Keys(schema.Defs.Pattern.OneOf[1].Properties))
+	assert.ElementsMatch(t, pathRuleKeys, lo.Keys(schema.Defs.PathRule.Properties))
+}